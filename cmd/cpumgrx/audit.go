@@ -0,0 +1,159 @@
+/*
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2026 Red Hat, Inc.
+ */
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/pflag"
+	"k8s.io/klog/v2"
+	"k8s.io/kubernetes/pkg/kubelet/cm/cpumanager/topology"
+	"k8s.io/utils/cpuset"
+
+	"github.com/ffromani/cpumgrx/pkg/audit"
+)
+
+// runAudit handles `cpumgrx audit`. The arguments don't include the subcommand.
+func runAudit(args []string) {
+	flags := pflag.NewFlagSet("audit", pflag.ExitOnError)
+	flags.AddFlagSet(pflag.CommandLine) // klog flags
+	var statePath string
+	var machineInfoPath string
+	var rawReservedCPUs string
+	var policyName string
+	var rawCPUPolicyOptions string
+	flags.StringVar(&statePath, "state", "", "cpu_manager_state file to check (\"-\" for stdin)")
+	flags.StringVarP(&machineInfoPath, "machine-info", "M", "", "machine info path")
+	flags.StringVarP(&rawReservedCPUs, "reserved-cpus", "R", "", "reserved CPUs of the node. If not given, the reserved CPUs are not checked")
+	flags.StringVarP(&policyName, "policy", "P", "", "CPU manager policy of the node. If not given, the policy of the checkpoint is assumed")
+	flags.StringVar(&rawCPUPolicyOptions, "cpu-policy-options", "", "CPU manager policy options of the node, as comma-separated key=value pairs")
+	flags.Parse(args)
+
+	if statePath == "" {
+		klog.Errorf("missing cpu_manager_state path")
+		os.Exit(1)
+	}
+	if machineInfoPath == "" {
+		klog.Errorf("missing machine info JSON path")
+		os.Exit(1)
+	}
+	opts := audit.Options{PolicyName: policyName}
+	if rawReservedCPUs != "" {
+		opts.ReservedCPUs = mustParseReservedCPUs(rawReservedCPUs)
+	}
+	for key, value := range mustParseKeyValues(rawCPUPolicyOptions) {
+		enabled, err := strconv.ParseBool(value)
+		if err != nil {
+			klog.Errorf("bad value for CPU manager policy option %q: %v", key, err)
+			os.Exit(1)
+		}
+		switch key {
+		case "full-pcpus-only":
+			opts.FullPCPUsOnly = enabled
+		case "strict-cpu-reservation":
+			opts.StrictCPUReservation = enabled
+		}
+	}
+
+	topo, err := topology.Discover(mustReadMachineInfo(machineInfoPath))
+	if err != nil {
+		klog.Errorf("topology discovery failed: %v", err)
+		os.Exit(1)
+	}
+
+	data, err := readStateFile(statePath)
+	if err != nil {
+		klog.Errorf("error reading %q: %v", statePath, err)
+		os.Exit(1)
+	}
+	rp, err := audit.Audit(data, topo, opts)
+	if err != nil {
+		klog.Errorf("cannot audit %q: %v", statePath, err)
+		os.Exit(1)
+	}
+
+	printCheckpointSummary(rp)
+	flagged := cpuset.New()
+	for _, is := range rp.Issues {
+		severity := "WARNING"
+		if is.Fatal {
+			severity = "ERROR"
+		}
+		fmt.Printf("%s: %s\n", severity, is.String())
+		flagged = flagged.Union(is.CPUs)
+	}
+	printAssignmentTable(os.Stdout, topo, rp.Checkpoint, opts.ReservedCPUs, flagged)
+
+	if rp.Fatal() {
+		os.Exit(exitRestartRefused)
+	}
+}
+
+func readStateFile(statePath string) ([]byte, error) {
+	if statePath == "-" {
+		return io.ReadAll(os.Stdin)
+	}
+	return os.ReadFile(statePath)
+}
+
+func printCheckpointSummary(rp audit.Report) {
+	cp := rp.Checkpoint
+	checksum := "missing"
+	if cp.Checksum != 0 {
+		checksum = fmt.Sprintf("%d", cp.Checksum)
+		for _, is := range rp.Issues {
+			if is.Problem == audit.ProblemCorrupt {
+				checksum += " (mismatch)"
+			}
+		}
+	}
+	fmt.Printf("checkpoint: format %s, policy %q, checksum %s, %d entries\n", cp.Format, cp.PolicyName, checksum, len(cp.Entries))
+}
+
+// printAssignmentTable shows who owns every CPU of the machine, marking the CPUs with issues.
+func printAssignmentTable(w io.Writer, topo *topology.CPUTopology, cp audit.Checkpoint, reserved, flagged cpuset.CPUSet) {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "CPU\tNUMA\tSOCKET\tUNCORE\tCORE\tOWNER")
+	for _, cpuID := range topo.CPUDetails.CPUs().List() {
+		info := topo.CPUDetails[cpuID]
+		var owners []string
+		// with strict-cpu-reservation the reserved CPUs are out of the default set
+		if reserved.Contains(cpuID) {
+			owners = append(owners, "reserved")
+		} else if cp.DefaultCPUSet.Contains(cpuID) {
+			owners = append(owners, "shared")
+		}
+		for _, ent := range cp.Entries {
+			if ent.CPUs.Contains(cpuID) {
+				owners = append(owners, ent.Name())
+			}
+		}
+		if len(owners) == 0 {
+			owners = append(owners, "-")
+		}
+		if flagged.Contains(cpuID) {
+			owners = append(owners, "<---")
+		}
+		fmt.Fprintf(tw, "%d\t%d\t%d\t%d\t%d\t%s\n", cpuID, info.NUMANodeID, info.SocketID, info.UncoreCacheID, info.CoreID, strings.Join(owners, " "))
+	}
+	tw.Flush()
+}
@@ -0,0 +1,43 @@
+/*
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2026 Red Hat, Inc.
+ */
+
+package main
+
+import (
+	"fmt"
+	"os"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+
+	"github.com/ffromani/cpumgrx/pkg/churn"
+)
+
+func runChurn(params churn.Params, shapes []*v1.Pod) {
+	rp, err := churn.Run(params, shapes)
+	if err != nil {
+		klog.Errorf("churn simulation failed: %v", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("%10s %8s %8s %8s %10s %6s %10s %6s %10s\n", "time", "events", "arrivals", "running", "rejected%", "free", "frag%", "split", "misalign%")
+	for _, smp := range rp.Samples {
+		fmt.Printf("%10.2f %8d %8d %8d %10.1f %6d %10.1f %6d %10.1f\n",
+			smp.Time, smp.Events, smp.Arrivals, smp.Running,
+			100*smp.RejectionRate, smp.FreeCPUs, 100*smp.Fragmentation, smp.SplitCores, 100*smp.Misaligned)
+	}
+	fmt.Printf("\narrivals: %d rejected: %d (%.1f%%) over %.2f time units\n", rp.Arrivals, rp.Rejected, 100*rp.RejectionRate(), rp.Duration)
+}
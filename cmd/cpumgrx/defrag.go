@@ -0,0 +1,79 @@
+/*
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2026 Red Hat, Inc.
+ */
+
+package main
+
+import (
+	"fmt"
+	"os"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+
+	"github.com/ffromani/cpumgrx/pkg/defrag"
+	"github.com/ffromani/cpumgrx/pkg/podtemplate"
+)
+
+func mustParseDefragTarget(target string) *v1.Pod {
+	if target == "numa" {
+		return nil
+	}
+	pods, err := podtemplate.Parse(target)
+	if err != nil {
+		klog.Errorf("bad defrag target: %v", err)
+		os.Exit(1)
+	}
+	if len(pods) != 1 {
+		klog.Errorf("bad defrag target %q: expected a single pod", target)
+		os.Exit(1)
+	}
+	return pods[0]
+}
+
+func runDefrag(params defrag.Params, pods []*v1.Pod) {
+	rp, err := defrag.Run(params, pods)
+	if err != nil {
+		klog.Errorf("defrag analysis failed: %v", err)
+		os.Exit(1)
+	}
+
+	if len(rp.Rejected) > 0 {
+		fmt.Printf("rejected, not part of the current state: %v\n", rp.Rejected)
+	}
+	if rp.AlreadyFits {
+		fmt.Printf("no action needed: %s\n", describeDefragGoal(rp.Suggestions[0]))
+		return
+	}
+	if len(rp.Suggestions) == 0 {
+		fmt.Printf("no suggestion found recreating up to %d pods\n", params.MaxVictims)
+		os.Exit(1)
+	}
+
+	fmt.Printf("recreate %d pod(s), %d option(s) found:\n", len(rp.Suggestions[0].Victims), len(rp.Suggestions))
+	for _, sugg := range rp.Suggestions {
+		fmt.Printf("- delete and recreate %v: %s\n", sugg.Victims, describeDefragGoal(sugg))
+		for _, pl := range sugg.Recreated {
+			fmt.Printf("\t%s: %s\n", pl.Name, pl.CPUs.String())
+		}
+	}
+}
+
+func describeDefragGoal(sugg defrag.Suggestion) string {
+	if sugg.Target != nil {
+		return fmt.Sprintf("%s fits on %s", sugg.Target.Name, sugg.Target.CPUs.String())
+	}
+	return fmt.Sprintf("NUMA node %d is free", sugg.FreeNUMANode)
+}
@@ -0,0 +1,56 @@
+/*
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2026 Red Hat, Inc.
+ */
+
+package main
+
+import (
+	"errors"
+
+	"github.com/ffromani/cpumgrx/pkg/cpumgrx"
+)
+
+// exit codes, so scripts can tell why the first pod was rejected.
+// 1 is left to usage and setup errors.
+const (
+	exitOK               = 0
+	exitInvalidRequest   = 2
+	exitSMTAlignment     = 3
+	exitTopologyAffinity = 4
+	exitInsufficientCPUs = 5
+	exitUnexpected       = 6
+	exitRestartRefused   = 7
+)
+
+func exitCodeFor(err error) int {
+	var smtErr *cpumgrx.SMTAlignmentError
+	var tmErr *cpumgrx.TopologyAffinityError
+	var cpusErr *cpumgrx.InsufficientCPUsError
+	var reqErr *cpumgrx.InvalidRequestError
+	switch {
+	case err == nil:
+		return exitOK
+	case errors.As(err, &reqErr):
+		return exitInvalidRequest
+	case errors.As(err, &smtErr):
+		return exitSMTAlignment
+	case errors.As(err, &tmErr):
+		return exitTopologyAffinity
+	case errors.As(err, &cpusErr):
+		return exitInsufficientCPUs
+	default:
+		return exitUnexpected
+	}
+}
@@ -0,0 +1,51 @@
+/*
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2026 Red Hat, Inc.
+ */
+
+package main
+
+import (
+	"fmt"
+
+	"k8s.io/kubernetes/pkg/kubelet/cm/cpumanager/topology"
+	"k8s.io/utils/cpuset"
+
+	"github.com/ffromani/cpumgrx/pkg/cpumgrx"
+	"github.com/ffromani/cpumgrx/pkg/explain"
+)
+
+// printExplanation tells how the exclusive CPUs of each container came together,
+// given the CPUs which were free before the pod was admitted.
+func printExplanation(topo *topology.CPUTopology, st explain.Strategy, freeBefore cpuset.CPUSet, res cpumgrx.Result) {
+	free := freeBefore
+	for _, cr := range res.Containers {
+		if !cr.Exclusive {
+			continue
+		}
+		affinity := "any NUMA node"
+		if cr.Affinity.NUMANodeAffinity != nil {
+			affinity = fmt.Sprintf("NUMA affinity %s", cr.Affinity.NUMANodeAffinity)
+		}
+		fmt.Printf("\t%s: %d exclusive CPUs out of %d free, %s, %s\n", cr.Name, cr.CPUs.Size(), free.Size(), affinity, st.String())
+		ex := explain.Explain(topo, st, free, cr.CPUs)
+		for _, step := range ex.Steps {
+			fmt.Printf("\t%s:   %s\n", cr.Name, step.String())
+		}
+		// app containers reuse the CPUs of init containers
+		if !cr.Init {
+			free = free.Difference(cr.CPUs)
+		}
+	}
+}
@@ -20,9 +20,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
-	"path/filepath"
-	"regexp"
 	"sort"
+	"strconv"
 	"strings"
 
 	"flag"
@@ -32,14 +31,24 @@ import (
 
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
-	k8syaml "k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/apimachinery/pkg/util/uuid"
 	"k8s.io/klog/v2"
 	"k8s.io/kubernetes/pkg/kubelet/cm/cpumanager/topology"
 	"k8s.io/kubernetes/pkg/kubelet/cm/topologymanager"
 	"k8s.io/utils/cpuset"
 
+	"github.com/ffromani/cpumgrx/pkg/churn"
 	"github.com/ffromani/cpumgrx/pkg/cpumgrx"
+	"github.com/ffromani/cpumgrx/pkg/defrag"
+	"github.com/ffromani/cpumgrx/pkg/explain"
+	"github.com/ffromani/cpumgrx/pkg/klogcapture"
+	"github.com/ffromani/cpumgrx/pkg/kubeletconfig"
+	"github.com/ffromani/cpumgrx/pkg/oracle"
+	"github.com/ffromani/cpumgrx/pkg/ordering"
+	"github.com/ffromani/cpumgrx/pkg/podtemplate"
+	"github.com/ffromani/cpumgrx/pkg/sharedpool"
 	"github.com/ffromani/cpumgrx/pkg/tmutils"
+	"github.com/ffromani/cpumgrx/pkg/workload"
 )
 
 func main() {
@@ -48,22 +57,95 @@ func main() {
 	// Add flags registered by imported packages
 	pflag.CommandLine.AddGoFlagSet(flag.CommandLine)
 
+	if len(os.Args) > 1 && os.Args[1] == "serve" {
+		runServe(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "reserved" {
+		runReserved(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "audit" {
+		runAudit(os.Args[2:])
+		return
+	}
+	// the shell takes the same flags, and the pods to start with as args
+	shellMode := len(os.Args) > 1 && os.Args[1] == "shell"
+	if shellMode {
+		os.Args = append(os.Args[:1], os.Args[2:]...)
+	}
+
 	var policyName string
 	var tmPolicyName string
+	var tmScopeName string
+	var rawCPUPolicyOptions string
+	var rawTMPolicyOptions string
+	var kubeletConfigPath string
+	var nodeName string
+	var rawFeatureGates string
+	var rawResizes []string
 	var rawHint string
 	var rawReservedCPUs string
+	var rawReservedQty string
 	var machineInfoPath string
 	var podTemplateMode bool
 	var keepState bool
 	var stateFileDirectory string
+	var saveStatePath string
+	var orderAnalysis bool
+	var maxOrders int
+	var seed int64
+	var oracleMode bool
+	var maxSteps int
+	var defragTarget string
+	var maxVictims int
+	var churnArrivals int
+	var arrivalRate float64
+	var meanLifetime float64
+	var lifetimeDist string
+	var sampleEvery int
+	var captureLog bool
+	var explainMode bool
+	var sharedPoolReport bool
+	var compareStrict bool
+	var restart bool
+	var restartChanges []string
 	pflag.StringVarP(&rawReservedCPUs, "reserved-cpus", "R", "0", "set reserved CPUs")
+	pflag.StringVar(&rawReservedQty, "reserved-quantity", "", "set the amount of reserved CPUs, like kube and system reserved cpu. Without -R the cpu manager picks them; with -R both are used as given, even if they don't match")
 	pflag.StringVarP(&rawHint, "hint", "H", "", "set topology manager hint")
 	pflag.StringVarP(&machineInfoPath, "machine-info", "M", "", "machine info path")
 	pflag.BoolVarP(&podTemplateMode, "pod-template-mode", "T", false, "pod template mode")
 	pflag.StringVarP(&policyName, "policy", "P", "static", "set CPU manager Policy")
-	pflag.StringVarP(&tmPolicyName, "tm-policy", "p", "single-numa-node", "set TM manager Policy")
+	pflag.StringVarP(&tmPolicyName, "tm-policy", "p", kubeletconfig.DefaultTopologyManagerPolicy, "set TM manager Policy")
+	pflag.StringVar(&tmScopeName, "tm-scope", "container", "set TM manager scope")
+	pflag.StringVar(&rawCPUPolicyOptions, "cpu-policy-options", "", "set CPU manager policy options, as comma-separated key=value pairs")
+	pflag.StringVar(&rawTMPolicyOptions, "tm-policy-options", "", "set TM manager policy options, as comma-separated key=value pairs")
+	pflag.StringVar(&kubeletConfigPath, "kubelet-config", "", "read the settings from this KubeletConfiguration file. Flags given explicitly override it")
+	pflag.StringVar(&nodeName, "node", "", "replay only the pods bound to this node, in creation order (e.g. from kubectl get pods -o json)")
+	pflag.StringVar(&rawFeatureGates, "feature-gates", "", "set feature gates, as comma-separated key=value pairs")
+	pflag.StringArrayVar(&rawResizes, "resize", nil, "once all the pods are admitted, resize in place pod[/container] to the given CPUs (pod=cpus). Can be repeated")
 	pflag.BoolVarP(&keepState, "keep-state", "k", false, "keep the cpu_manager_state file")
-	pflag.StringVarP(&stateFileDirectory, "state-dir", "s", ".", "directory to store the cpu_manager_state_file")
+	pflag.CommandLine.MarkDeprecated("keep-state", "the state is kept in memory, use --save-state to write it")
+	pflag.StringVarP(&stateFileDirectory, "state-dir", "s", "", "load and store the cpu_manager_state file in this directory, like the kubelet does. If not given, the state is kept in memory")
+	pflag.StringVar(&saveStatePath, "save-state", "", "once done, write the state like the cpu_manager_state file to this path (\"-\" for stdout)")
+	pflag.BoolVarP(&orderAnalysis, "order-analysis", "O", false, "run all the admission orders of the given pods and report the order-sensitive ones")
+	pflag.IntVar(&maxOrders, "max-orders", ordering.DefaultMaxOrders, "evaluate a random sample of this many orders if the pods admit more permutations")
+	pflag.Int64Var(&seed, "seed", 1, "random seed used when sampling admission orders and in churn simulation")
+	pflag.BoolVar(&oracleMode, "oracle", false, "compare the allocations with the best aligned ones found by a bounded heuristic search")
+	pflag.IntVar(&maxSteps, "max-steps", oracle.DefaultMaxSteps, "maximum amount of steps of the oracle search")
+	pflag.IntVar(&maxVictims, "max-victims", defrag.DefaultMaxVictims, "maximum amount of pods to recreate when looking for defrag suggestions")
+	pflag.StringVar(&defragTarget, "defrag", "", "suggest the fewest pods to recreate to free a NUMA node (\"numa\") or to fit a pod (\"name=REQUEST/LIMIT\")")
+	pflag.IntVar(&churnArrivals, "churn", 0, "simulate this many pod arrivals and departures, picking the shapes among the given pods")
+	pflag.Float64Var(&arrivalRate, "arrival-rate", churn.DefaultArrivalRate, "mean pod arrivals per time unit in churn simulation")
+	pflag.Float64Var(&meanLifetime, "mean-lifetime", churn.DefaultMeanLifetime, "mean pod lifetime in time units in churn simulation")
+	pflag.StringVar(&lifetimeDist, "lifetime-dist", churn.LifetimeExponential, "pod lifetime distribution in churn simulation: exponential, fixed, uniform")
+	pflag.IntVar(&sampleEvery, "sample-every", churn.DefaultSampleEvery, "events between samples in churn simulation")
+	pflag.BoolVar(&captureLog, "capture-log", false, "show what the kubelet managers log about each pod next to it, instead of on stderr")
+	pflag.BoolVar(&explainMode, "explain", false, "explain which phases of the static policy picked the exclusive CPUs of each pod")
+	pflag.BoolVar(&sharedPoolReport, "shared-pool", false, "after each pod, report the shared pool size against the demand of the containers running on it")
+	pflag.BoolVar(&compareStrict, "compare-strict-reservation", false, "after each pod, show the shared pool per QoS class with and without the strict-cpu-reservation option")
+	pflag.BoolVar(&restart, "restart", false, "once all the pods are admitted, restart the kubelet and report what happens to the exclusive CPUs")
+	pflag.StringArrayVar(&restartChanges, "restart-with", nil, "restart the kubelet with this change (policy, tm-policy, cpu-policy-options, feature-gates, reserved-cpus, reserved-quantity, machine-info or offline-cpus=value). Can be repeated")
 	pflag.Parse()
 
 	args := pflag.Args()
@@ -72,38 +154,141 @@ func main() {
 		klog.Errorf("missing machine info JSON path")
 		os.Exit(1)
 	}
-	if len(args) == 0 {
+	if len(args) == 0 && !shellMode {
 		klog.Errorf("missing args")
 		os.Exit(1)
 	}
 
-	reservedCPUSet := mustParseReservedCPUs(rawReservedCPUs)
 	params := cpumgrx.Params{
 		PolicyName:         policyName,
 		TMPolicyName:       tmPolicyName,
+		TMScopeName:        tmScopeName,
+		TMPolicyOptions:    mustParseKeyValues(rawTMPolicyOptions),
+		CPUPolicyOptions:   mustParseKeyValues(rawCPUPolicyOptions),
+		FeatureGates:       make(map[string]bool),
 		StateFileDirectory: stateFileDirectory,
-		ReservedCPUSet:     reservedCPUSet,
-		ReservedCPUQty:     resource.MustParse(fmt.Sprintf("%d", reservedCPUSet.Size())),
 		MachineInfo:        mustReadMachineInfo(machineInfoPath),
 	}
+	// without a kubelet config, all the flags count, default values included
+	flagGiven := func(name string) bool {
+		return kubeletConfigPath == "" || pflag.CommandLine.Changed(name)
+	}
+	if kubeletConfigPath != "" {
+		mustApplyKubeletConfig(kubeletConfigPath, &params)
+	}
+	if flagGiven("policy") {
+		params.PolicyName = policyName
+	}
+	if flagGiven("tm-policy") {
+		params.TMPolicyName = tmPolicyName
+	}
+	if flagGiven("tm-scope") {
+		params.TMScopeName = tmScopeName
+	}
+	if flagGiven("tm-policy-options") {
+		params.TMPolicyOptions = mustParseKeyValues(rawTMPolicyOptions)
+	}
+	if flagGiven("cpu-policy-options") {
+		params.CPUPolicyOptions = mustParseKeyValues(rawCPUPolicyOptions)
+	}
+	reservedQtyGiven := pflag.CommandLine.Changed("reserved-quantity")
+	if flagGiven("reserved-cpus") && (pflag.CommandLine.Changed("reserved-cpus") || !reservedQtyGiven) {
+		params.ReservedCPUSet = mustParseReservedCPUs(rawReservedCPUs)
+		params.ReservedCPUQty = resource.MustParse(fmt.Sprintf("%d", params.ReservedCPUSet.Size()))
+	}
+	if reservedQtyGiven {
+		mustApplyReservedQuantity(rawReservedQty, &params, pflag.CommandLine.Changed("reserved-cpus"))
+	}
+
+	resizes := mustParseResizes(rawResizes)
+	// like the kubelet, the gates given on the command line are merged with the configured ones
+	if params.FeatureGates == nil {
+		params.FeatureGates = make(map[string]bool)
+	}
+	for name, enabled := range mustParseFeatureGates(rawFeatureGates) {
+		params.FeatureGates[name] = enabled
+	}
+	if _, ok := params.FeatureGates["InPlacePodVerticalScaling"]; len(resizes) > 0 && !ok {
+		params.FeatureGates["InPlacePodVerticalScaling"] = true
+	}
 	if rawHint != "" {
 		params.Hint = mustParseHint(rawHint)
 	}
+	reservedPicked := mustPickReservedCPUs(&params)
 
 	var pods []*v1.Pod
 
 	if podTemplateMode {
-		cpuReqs := parseCpuReqs(args)
-		for _, cpuReq := range cpuReqs {
-			pods = append(pods, makePod(cpuReq))
+		var err error
+		pods, err = podtemplate.ParseAll(args)
+		if err != nil {
+			klog.Errorf("%v", err)
+			os.Exit(1)
 		}
 	} else {
 		podSpecPaths := args
 		for _, podSpecPath := range podSpecPaths {
-			pods = append(pods, mustReadPodSpec(podSpecPath))
+			pods = append(pods, mustReadPods(podSpecPath)...)
+		}
+		if nodeName != "" {
+			pods = workload.OnNode(pods, nodeName)
+			if len(pods) == 0 {
+				klog.Errorf("no pods found on node %q", nodeName)
+				os.Exit(1)
+			}
+		}
+	}
+
+	for _, pod := range pods {
+		if pod.UID == "" {
+			pod.UID = uuid.NewUUID()
 		}
 	}
 
+	if shellMode {
+		runShell(params, pods)
+		return
+	}
+
+	if orderAnalysis {
+		runOrderAnalysis(ordering.Params{
+			Manager:   params,
+			MaxOrders: maxOrders,
+			Seed:      seed,
+		}, pods)
+		return
+	}
+
+	if oracleMode {
+		runOracle(oracle.Params{
+			Manager:  params,
+			MaxSteps: maxSteps,
+		}, pods)
+		return
+	}
+
+	if defragTarget != "" {
+		runDefrag(defrag.Params{
+			Manager:    params,
+			MaxVictims: maxVictims,
+			Target:     mustParseDefragTarget(defragTarget),
+		}, pods)
+		return
+	}
+
+	if churnArrivals > 0 {
+		runChurn(churn.Params{
+			Manager:              params,
+			Arrivals:             churnArrivals,
+			ArrivalRate:          arrivalRate,
+			MeanLifetime:         meanLifetime,
+			LifetimeDistribution: lifetimeDist,
+			SampleEvery:          sampleEvery,
+			Seed:                 seed,
+		}, pods)
+		return
+	}
+
 	topo, err := topology.Discover(params.MachineInfo)
 	if err != nil {
 		klog.Errorf("topology discovery failed: %v", err)
@@ -112,43 +297,62 @@ func main() {
 
 	cpuDetails := CPUDetails{topo.CPUDetails}
 
+	var restartParams cpumgrx.Params
+	if restart || len(restartChanges) > 0 {
+		restartParams = mustApplyRestartChanges(params, reservedPicked, restartChanges)
+	}
+
 	mgrx, err := cpumgrx.NewFromParams(params)
 	if err != nil {
 		klog.Errorf("cpumanager creation failed: %v", err)
 		os.Exit(1)
 	}
+	if reservedPicked {
+		fmt.Printf("reserved: %s\n", params.ReservedCPUSet.String())
+	}
 
-	defer func() {
-		if keepState {
-			return
-		}
-		fullPath := filepath.Join(stateFileDirectory, "cpu_manager_state")
-		klog.V(3).Infof("removing cpu_manager_state file on %q", fullPath)
-		err := os.Remove(fullPath)
-		if err != nil {
-			klog.Warning("error removing %q: %v", fullPath, err)
-		}
-	}()
+	defer mgrx.Close()
 
 	// coreID -> virtual cores (threads) per physical core
 	coreInfo := make(map[int]cpuset.CPUSet)
 	// coreID -> pod names allowed to run on that core
 	coreTenants := make(map[int][]string)
-	for _, cpuID := range reservedCPUSet.List() {
+	for _, cpuID := range params.ReservedCPUSet.List() {
 		coreID, _ := cpuDetails.CoreSiblings(cpuID)
 		coreTenants[coreID] = []string{"reserved"}
 	}
 
+	if captureLog {
+		capture := klogcapture.Start(klogcapture.DefaultVerbosity)
+		defer capture.Stop()
+	}
+
+	strategy, err := explain.StrategyFromOptions(params.CPUPolicyOptions)
+	if err != nil {
+		klog.Errorf("bad CPU manager policy options: %v", err)
+		os.Exit(1)
+	}
+
+	exitCode := exitOK
+	var admitted []*v1.Pod
 	for _, pod := range pods {
 		if blob, err := json.Marshal(pod); err == nil {
 			klog.V(4).Infof("handling pod: %s", string(blob))
 		}
 
-		cpus, err := mgrx.Run(pod)
+		freeBefore := mgrx.GetFreeCPUs()
+		res, err := mgrx.Run(pod)
 		if err != nil {
-			klog.Errorf("cpumanager allocation failed: %v", err)
+			fmt.Printf("%s: %s (cause: %s)\n", pod.Name, res.String(), res.Cause)
+			printLog(res.Log)
+			printPoolReports(mgrx, admitted, sharedPoolReport, compareStrict)
+			if exitCode == exitOK {
+				exitCode = exitCodeFor(err)
+			}
 			continue
 		}
+		admitted = append(admitted, pod)
+		cpus := mgrx.GetCPUs(pod)
 		podCoreInfo := partitionCPUsByCore(cpus, cpuDetails)
 		for coreID, cs := range podCoreInfo {
 			// TODO: explain overwrite
@@ -157,9 +361,40 @@ func main() {
 		}
 
 		printCPUs(pod.Name, cpus, podCoreInfo)
+		printContainers(res)
+		printQOS(res)
+		if explainMode {
+			printExplanation(topo, strategy, freeBefore, res)
+		}
+		printLog(res.Log)
+		printPoolReports(mgrx, admitted, sharedPoolReport, compareStrict)
+	}
+
+	runResizes(mgrx, admitted, resizes)
+
+	if restart || len(restartChanges) > 0 {
+		restarted, err := runRestart(mgrx, restartParams, admitted, coreTenants)
+		if err != nil {
+			klog.Errorf("restart failed: %v", err)
+			os.Exit(1)
+		}
+		if restarted == nil {
+			os.Exit(exitRestartRefused)
+		}
+		mgrx = restarted
 	}
 
 	printCoreTenants(coreTenants)
+
+	if saveStatePath != "" {
+		if err := saveState(mgrx, saveStatePath); err != nil {
+			klog.Errorf("error saving the state to %q: %v", saveStatePath, err)
+			os.Exit(1)
+		}
+	}
+	if exitCode != exitOK {
+		os.Exit(exitCode)
+	}
 }
 
 type CPUDetails struct {
@@ -204,6 +439,69 @@ func printCPUs(podName string, cpus cpuset.CPUSet, coreInfo map[int]cpuset.CPUSe
 	fmt.Printf("%s\n", b.String())
 }
 
+// printContainers tells the CPUs of each container, for pods with more than one.
+// The pod line only shows the ones of the first app container.
+func printContainers(res cpumgrx.Result) {
+	if len(res.Containers) < 2 {
+		return
+	}
+	for _, cr := range res.Containers {
+		kind := "shared"
+		if cr.Exclusive {
+			kind = "exclusive"
+		}
+		if cr.Init {
+			kind += ", init"
+		}
+		fmt.Printf("\t%s: %s (%s)\n", cr.Name, cr.CPUs.String(), kind)
+	}
+}
+
+// printQOS tells the QoS class of the pod, and why the containers got no exclusive CPUs, if so.
+func printQOS(res cpumgrx.Result) {
+	fmt.Printf("\tqos: %s\n", res.QOSClass)
+	for _, cr := range res.Containers {
+		if cr.SharedReason != "" {
+			fmt.Printf("\t%s: no exclusive CPUs: %s\n", cr.Name, cr.SharedReason)
+		}
+	}
+}
+
+func printPoolReports(mgrx *cpumgrx.CpuMgrx, admitted []*v1.Pod, sharedPoolReport, compareStrict bool) {
+	if sharedPoolReport {
+		rp := sharedpool.Compute(mgrx, admitted)
+		fmt.Printf("\t%s\n", rp.String())
+		if rp.Undersized() {
+			fmt.Printf("\tWARNING: the shared containers request %s CPUs, more than the %d left in the shared pool\n", rp.Requests.String(), rp.CPUs.Size())
+		}
+	}
+	if compareStrict {
+		fmt.Printf("\tshared pool without / with strict-cpu-reservation:\n")
+		strictEmpty := false
+		affected := 0
+		for _, pl := range sharedpool.ComparePlacements(mgrx, admitted) {
+			fmt.Printf("\t  %s: %d containers on %q (%d CPUs) / %q (%d CPUs)\n", pl.QOSClass, pl.Containers, pl.Loose.String(), pl.Loose.Size(), pl.Strict.String(), pl.Strict.Size())
+			if pl.StrictEmpty() {
+				strictEmpty = true
+				affected += pl.Containers
+			}
+		}
+		if strictEmpty {
+			fmt.Printf("\tWARNING: with strict-cpu-reservation the shared pool is empty, %d running containers and all new non-exclusive ones would have no CPUs to run on\n", affected)
+		}
+	}
+}
+
+func printLog(entries []klogcapture.Entry) {
+	for _, entry := range entries {
+		if entry.Container == "" {
+			fmt.Printf("\t%s\n", entry.String())
+			continue
+		}
+		fmt.Printf("\t%s: %s\n", entry.Container, entry.String())
+	}
+}
+
 func printCoreTenants(coreTenants map[int][]string) {
 	var coreIDs []int
 	for coreID := range coreTenants {
@@ -220,55 +518,6 @@ func printCoreTenants(coreTenants map[int][]string) {
 	}
 }
 
-type cpuReqSpec struct {
-	Name     string
-	Limits   resource.Quantity
-	Requests resource.Quantity
-}
-
-// name=request/limit
-func parseCpuReqs(args []string) []cpuReqSpec {
-	var reqsRE = regexp.MustCompile(`^(\S*)=(\S*)/(\S*)$`)
-	var cpuReqs []cpuReqSpec
-	for _, arg := range args {
-		items := reqsRE.FindAllStringSubmatch(arg, -1)
-		// items[0] is the full match
-		if items == nil || len(items[0]) != 4 {
-			klog.Warningf("cannot parse cpu req spec %q - skipped", arg)
-			continue
-		}
-		cpuReqs = append(cpuReqs, cpuReqSpec{
-			Name:     items[0][1],
-			Limits:   resource.MustParse(items[0][2]),
-			Requests: resource.MustParse(items[0][3]),
-		})
-	}
-	return cpuReqs
-}
-
-func makePod(cpuReq cpuReqSpec) *v1.Pod {
-	pod := v1.Pod{
-		Spec: v1.PodSpec{
-			Containers: []v1.Container{
-				v1.Container{
-					Resources: v1.ResourceRequirements{
-						Limits:   make(v1.ResourceList),
-						Requests: make(v1.ResourceList),
-					},
-				},
-			},
-		},
-	}
-	// yep, that's the lazy way
-	pod.Name = fmt.Sprintf("%s-pod", cpuReq.Name)
-	pod.Spec.Containers[0].Name = fmt.Sprintf("%s-cnt", cpuReq.Name)
-	pod.Spec.Containers[0].Resources.Requests[v1.ResourceCPU] = cpuReq.Requests
-	pod.Spec.Containers[0].Resources.Limits[v1.ResourceCPU] = cpuReq.Limits
-	pod.Spec.Containers[0].Resources.Requests[v1.ResourceMemory] = resource.MustParse("1Gi")
-	pod.Spec.Containers[0].Resources.Limits[v1.ResourceMemory] = resource.MustParse("1Gi")
-	return &pod
-}
-
 func mustParseHint(rawHint string) topologymanager.TopologyHint {
 	hints, err := tmutils.ParseGOHints([]string{rawHint})
 	if err != nil {
@@ -288,6 +537,81 @@ func mustParseHint(rawHint string) topologymanager.TopologyHint {
 	return topologymanager.TopologyHint{}
 }
 
+func mustApplyKubeletConfig(kubeletConfigPath string, params *cpumgrx.Params) {
+	kc, err := kubeletconfig.Read(kubeletConfigPath)
+	if err != nil {
+		klog.Errorf("error reading %q: %v", kubeletConfigPath, err)
+		os.Exit(1)
+	}
+	if err := kc.Apply(params); err != nil {
+		klog.Errorf("error applying %q: %v", kubeletConfigPath, err)
+		os.Exit(1)
+	}
+}
+
+func mustParseKeyValues(rawOptions string) map[string]string {
+	options := make(map[string]string)
+	if rawOptions == "" {
+		return options
+	}
+	for _, item := range strings.Split(rawOptions, ",") {
+		key, value, ok := strings.Cut(item, "=")
+		if !ok {
+			klog.Errorf("bad format for key=value pair %q", item)
+			os.Exit(1)
+		}
+		options[strings.TrimSpace(key)] = strings.TrimSpace(value)
+	}
+	return options
+}
+
+func mustParseFeatureGates(rawGates string) map[string]bool {
+	gates := make(map[string]bool)
+	for key, value := range mustParseKeyValues(rawGates) {
+		enabled, err := strconv.ParseBool(value)
+		if err != nil {
+			klog.Errorf("bad value for feature gate %q: %v", key, err)
+			os.Exit(1)
+		}
+		gates[key] = enabled
+	}
+	return gates
+}
+
+// mustApplyReservedQuantity sets the amount of reserved CPUs. Unless the set is kept, the cpu manager picks them.
+func mustApplyReservedQuantity(rawQty string, params *cpumgrx.Params, keepSet bool) {
+	qty, err := resource.ParseQuantity(rawQty)
+	if err != nil {
+		klog.Errorf("bad reserved quantity %q: %v", rawQty, err)
+		os.Exit(1)
+	}
+	params.ReservedCPUQty = qty
+	if !keepSet {
+		params.ReservedCPUSet = cpuset.New()
+		return
+	}
+	// the kubelet rounds the amount up to whole CPUs
+	if numCPUs := (qty.MilliValue() + 999) / 1000; numCPUs != int64(params.ReservedCPUSet.Size()) {
+		klog.Warningf("reserved quantity %s doesn't match the %d reserved CPUs %q", qty.String(), params.ReservedCPUSet.Size(), params.ReservedCPUSet.String())
+	}
+}
+
+// mustPickReservedCPUs resolves once the reserved CPUs the static policy picks out of their amount,
+// so all the managers we create agree on them. It tells whether it picked them.
+func mustPickReservedCPUs(params *cpumgrx.Params) bool {
+	if params.PolicyName != "static" || !params.ReservedCPUSet.IsEmpty() || params.ReservedCPUQty.IsZero() {
+		return false
+	}
+	reserved, err := cpumgrx.PickReservedCPUs(*params)
+	if err != nil {
+		klog.Errorf("cannot pick %s reserved CPUs: %v", params.ReservedCPUQty.String(), err)
+		os.Exit(1)
+	}
+	klog.Infof("picked %s reserved CPUs: %s", params.ReservedCPUQty.String(), reserved.String())
+	params.ReservedCPUSet = reserved
+	return true
+}
+
 func mustParseReservedCPUs(rawReservedCPUs string) cpuset.CPUSet {
 	reservedCPUs, err := cpuset.Parse(rawReservedCPUs)
 	if err != nil {
@@ -315,21 +639,30 @@ func mustReadMachineInfo(machineInfoPath string) *cadvisorapi.MachineInfo {
 	return &machineInfo
 }
 
-func mustReadPodSpec(podSpecPath string) *v1.Pod {
-	var pod v1.Pod
-
-	src, err := os.Open(podSpecPath)
+func saveState(mgrx *cpumgrx.CpuMgrx, path string) error {
+	if path == "-" {
+		if err := mgrx.WriteCheckpoint(os.Stdout); err != nil {
+			return err
+		}
+		fmt.Println()
+		return nil
+	}
+	dst, err := os.Create(path)
 	if err != nil {
-		klog.Errorf("error opening %q: %v", podSpecPath, err)
-		os.Exit(1)
+		return err
 	}
-	defer src.Close()
+	if err := mgrx.WriteCheckpoint(dst); err != nil {
+		dst.Close()
+		return err
+	}
+	return dst.Close()
+}
 
-	dec := k8syaml.NewYAMLOrJSONDecoder(src, 1024)
-	if err := dec.Decode(&pod); err != nil {
-		klog.Errorf("error decoding %q: %v", podSpecPath, err)
+func mustReadPods(path string) []*v1.Pod {
+	pods, err := workload.ReadFile(path)
+	if err != nil {
+		klog.Errorf("error reading %q: %v", path, err)
 		os.Exit(1)
 	}
-
-	return &pod
+	return pods
 }
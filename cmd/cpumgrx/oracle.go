@@ -0,0 +1,62 @@
+/*
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2026 Red Hat, Inc.
+ */
+
+package main
+
+import (
+	"fmt"
+	"os"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+
+	"github.com/ffromani/cpumgrx/pkg/oracle"
+)
+
+func runOracle(params oracle.Params, pods []*v1.Pod) {
+	rp, err := oracle.Run(params, pods)
+	if err != nil {
+		klog.Errorf("oracle search failed: %v", err)
+		os.Exit(1)
+	}
+
+	for _, pr := range rp.Pods {
+		if pr.Request == 0 {
+			fmt.Printf("%s: shared pool\n", pr.Name)
+			continue
+		}
+		mark := ""
+		if pr.Gap() > 0 {
+			mark = " <---"
+		}
+		fmt.Printf("%s: request=%d gap=%d%s\n", pr.Name, pr.Request, pr.Gap(), mark)
+		fmt.Printf("\tgreedy:  %s\n", describeAllocation(pr.Greedy))
+		fmt.Printf("\tbest:    %s\n", describeAllocation(pr.Best))
+	}
+
+	kind := "all candidates tried"
+	if rp.Truncated {
+		kind = "truncated, step limit reached"
+	}
+	fmt.Printf("\ntotal penalty: greedy=%d best found=%d (heuristic search: %d steps, %s)\n", rp.GreedyPenalty, rp.BestPenalty, rp.Steps, kind)
+}
+
+func describeAllocation(alloc oracle.Allocation) string {
+	if alloc.Rejected {
+		return "rejected"
+	}
+	return fmt.Sprintf("[%s] %s penalty=%d", alloc.CPUs.String(), alloc.Info.String(), alloc.Penalty)
+}
@@ -0,0 +1,62 @@
+/*
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2026 Red Hat, Inc.
+ */
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+
+	"github.com/ffromani/cpumgrx/pkg/ordering"
+)
+
+func runOrderAnalysis(params ordering.Params, pods []*v1.Pod) {
+	rp, err := ordering.Run(params, pods)
+	if err != nil {
+		klog.Errorf("order analysis failed: %v", err)
+		os.Exit(1)
+	}
+
+	kind := "all"
+	if rp.Sampled {
+		kind = "sampled"
+	}
+	badOutcomes := rp.BadOutcomes()
+	fmt.Printf("orders evaluated: %d (%s), with rejections or misalignment: %d\n", rp.Evaluated, kind, len(badOutcomes))
+	for _, oc := range badOutcomes {
+		fmt.Printf("[%s] rejected=%v misaligned=%v\n", strings.Join(oc.Order, " "), oc.Rejected, oc.Misaligned)
+	}
+
+	fmt.Printf("\n")
+	for _, ps := range rp.Pods {
+		mark := ""
+		if ps.IsOrderSensitive() {
+			mark = " <---"
+		}
+		fmt.Printf("%s: good=%d rejected=%d misaligned=%d%s\n", ps.Name, ps.Good, ps.Rejected, ps.Misaligned, mark)
+	}
+
+	ps, ok := rp.MostSensitive()
+	if !ok {
+		fmt.Printf("\nno pod is sensitive to the admission order\n")
+		return
+	}
+	fmt.Printf("\nmost order-sensitive pod: %s (bad outcome in %d/%d orders)\n", ps.Name, ps.Bad(), rp.Evaluated)
+}
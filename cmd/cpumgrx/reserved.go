@@ -0,0 +1,92 @@
+/*
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2026 Red Hat, Inc.
+ */
+
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/pflag"
+	"k8s.io/klog/v2"
+	"k8s.io/kubernetes/pkg/kubelet/cm/cpumanager/topology"
+
+	"github.com/ffromani/cpumgrx/pkg/reservedset"
+)
+
+const defaultMaxProposals = 5
+
+// runReserved handles `cpumgrx reserved`. The arguments don't include the subcommand.
+func runReserved(args []string) {
+	flags := pflag.NewFlagSet("reserved", pflag.ExitOnError)
+	flags.AddFlagSet(pflag.CommandLine) // klog flags
+	var machineInfoPath string
+	var rawReservedCPUs string
+	var size int
+	var rawLayout string
+	var maxProposals int
+	flags.StringVarP(&machineInfoPath, "machine-info", "M", "", "machine info path")
+	flags.StringVarP(&rawReservedCPUs, "reserved-cpus", "R", "", "check this reserved CPU set")
+	flags.IntVar(&size, "size", 0, "propose reserved CPU sets of this size")
+	flags.StringVar(&rawLayout, "layout", string(reservedset.LayoutPacked), "how the reserved CPUs should sit across NUMA nodes: packed, spread")
+	flags.IntVar(&maxProposals, "max-proposals", defaultMaxProposals, "propose at most this many reserved CPU sets")
+	flags.Parse(args)
+
+	if machineInfoPath == "" {
+		klog.Errorf("missing machine info JSON path")
+		os.Exit(1)
+	}
+	if rawReservedCPUs == "" && size == 0 {
+		klog.Errorf("nothing to do: give the reserved CPUs to check (-R) or the size of the sets to propose (--size)")
+		os.Exit(1)
+	}
+	layout := reservedset.Layout(rawLayout)
+	if layout != reservedset.LayoutPacked && layout != reservedset.LayoutSpread {
+		klog.Errorf("unknown layout %q", rawLayout)
+		os.Exit(1)
+	}
+
+	topo, err := topology.Discover(mustReadMachineInfo(machineInfoPath))
+	if err != nil {
+		klog.Errorf("topology discovery failed: %v", err)
+		os.Exit(1)
+	}
+
+	if rawReservedCPUs != "" {
+		reserved := mustParseReservedCPUs(rawReservedCPUs)
+		issues := reservedset.Validate(topo, reserved, layout)
+		if len(issues) == 0 {
+			fmt.Printf("reserved %q: ok\n", reserved.String())
+		}
+		for _, is := range issues {
+			fmt.Printf("reserved %q: WARNING: %s\n", reserved.String(), is.String())
+		}
+	}
+
+	if size > 0 {
+		candidates, err := reservedset.Propose(topo, size, layout, maxProposals)
+		if err != nil {
+			klog.Errorf("cannot propose reserved CPUs: %v", err)
+			os.Exit(1)
+		}
+		for idx, cand := range candidates {
+			fmt.Printf("%d. %s\n", idx+1, cand.CPUs.String())
+			for _, is := range cand.Issues {
+				fmt.Printf("\t%s\n", is.String())
+			}
+		}
+	}
+}
@@ -0,0 +1,98 @@
+/*
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2026 Red Hat, Inc.
+ */
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/klog/v2"
+
+	"github.com/ffromani/cpumgrx/pkg/cpumgrx"
+)
+
+type resizeSpec struct {
+	PodName       string
+	ContainerName string
+	CPUs          resource.Quantity
+}
+
+// pod[/container]=cpus
+func mustParseResizes(args []string) []resizeSpec {
+	var specs []resizeSpec
+	for _, arg := range args {
+		target, rawCPUs, ok := strings.Cut(arg, "=")
+		if !ok {
+			klog.Errorf("bad format for resize %q", arg)
+			os.Exit(1)
+		}
+		cpus, err := resource.ParseQuantity(rawCPUs)
+		if err != nil {
+			klog.Errorf("bad cpu quantity for resize %q: %v", arg, err)
+			os.Exit(1)
+		}
+		podName, containerName, _ := strings.Cut(target, "/")
+		specs = append(specs, resizeSpec{
+			PodName:       podName,
+			ContainerName: containerName,
+			CPUs:          cpus,
+		})
+	}
+	return specs
+}
+
+// runResizes resizes the admitted pods, which it updates in place when the resize is admitted.
+func runResizes(mgrx *cpumgrx.CpuMgrx, pods []*v1.Pod, specs []resizeSpec) {
+	for _, spec := range specs {
+		idx := -1
+		for i, pod := range pods {
+			if pod.Name == spec.PodName {
+				idx = i
+				break
+			}
+		}
+		if idx == -1 {
+			klog.Errorf("resize: no admitted pod %q", spec.PodName)
+			continue
+		}
+		containerName := spec.ContainerName
+		if containerName == "" {
+			containerName = pods[idx].Spec.Containers[0].Name
+		}
+
+		pod, rr, err := mgrx.Resize(pods[idx], containerName, spec.CPUs)
+		if err != nil {
+			klog.Errorf("resize failed: %v", err)
+			continue
+		}
+		pods[idx] = pod
+
+		target := fmt.Sprintf("%s/%s cpu=%s", spec.PodName, containerName, spec.CPUs.String())
+		if !rr.Admit {
+			fmt.Printf("resize %s: %s (cause: %s), deferred: %s\n", target, rr.String(), rr.Cause, rr.After.String())
+			continue
+		}
+		mark := ""
+		if !rr.IsConsistent() {
+			mark = fmt.Sprintf(" <--- %d exclusive CPUs, %d requested", rr.ExclusiveCPUs, rr.RequestedCPUs)
+		}
+		fmt.Printf("resize %s: %s: %s -> %s%s\n", target, rr.String(), rr.Before.String(), rr.After.String(), mark)
+	}
+}
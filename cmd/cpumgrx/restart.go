@@ -0,0 +1,140 @@
+/*
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2026 Red Hat, Inc.
+ */
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	cadvisorapi "github.com/google/cadvisor/info/v1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/klog/v2"
+	"k8s.io/utils/cpuset"
+
+	"github.com/ffromani/cpumgrx/pkg/cpumgrx"
+)
+
+// mustApplyRestartChanges returns the params the kubelet restarts with, given changes like key=value.
+// If the reserved CPUs were picked out of their amount, a new amount makes the kubelet pick them again.
+func mustApplyRestartChanges(params cpumgrx.Params, reservedPicked bool, changes []string) cpumgrx.Params {
+	gates := make(map[string]bool)
+	for name, enabled := range params.FeatureGates {
+		gates[name] = enabled
+	}
+	params.FeatureGates = gates
+
+	for _, change := range changes {
+		key, value, ok := strings.Cut(change, "=")
+		if !ok {
+			klog.Errorf("bad format for restart change %q, expected key=value", change)
+			os.Exit(1)
+		}
+		switch key {
+		case "policy":
+			params.PolicyName = value
+		case "tm-policy":
+			params.TMPolicyName = value
+		case "cpu-policy-options":
+			params.CPUPolicyOptions = mustParseKeyValues(value)
+		case "feature-gates":
+			for name, enabled := range mustParseFeatureGates(value) {
+				params.FeatureGates[name] = enabled
+			}
+		case "reserved-cpus":
+			params.ReservedCPUSet = mustParseReservedCPUs(value)
+			params.ReservedCPUQty = resource.MustParse(fmt.Sprintf("%d", params.ReservedCPUSet.Size()))
+			reservedPicked = false
+		case "reserved-quantity":
+			mustApplyReservedQuantity(value, &params, !reservedPicked)
+		case "machine-info":
+			params.MachineInfo = mustReadMachineInfo(value)
+		case "offline-cpus":
+			params.MachineInfo = offlineCPUs(params.MachineInfo, mustParseReservedCPUs(value))
+		default:
+			klog.Errorf("unknown restart change %q", key)
+			os.Exit(1)
+		}
+	}
+	return params
+}
+
+// offlineCPUs returns a copy of the machine info without the given CPUs, like the kubelet finds it
+// once they are offlined. Cores left without threads disappear.
+func offlineCPUs(machineInfo *cadvisorapi.MachineInfo, cpus cpuset.CPUSet) *cadvisorapi.MachineInfo {
+	res := *machineInfo
+	res.Topology = make([]cadvisorapi.Node, 0, len(machineInfo.Topology))
+	removed := 0
+	for _, node := range machineInfo.Topology {
+		cores := make([]cadvisorapi.Core, 0, len(node.Cores))
+		for _, core := range node.Cores {
+			var threads []int
+			for _, thread := range core.Threads {
+				if cpus.Contains(thread) {
+					removed++
+					continue
+				}
+				threads = append(threads, thread)
+			}
+			if len(threads) == 0 {
+				continue
+			}
+			core.Threads = threads
+			cores = append(cores, core)
+		}
+		node.Cores = cores
+		res.Topology = append(res.Topology, node)
+	}
+	res.NumCores -= removed
+	return &res
+}
+
+// runRestart restarts the kubelet with the given params, and returns the manager which took over,
+// or nil if the kubelet would refuse to start. The pods rejected after the restart leave the cores.
+func runRestart(mgrx *cpumgrx.CpuMgrx, params cpumgrx.Params, pods []*v1.Pod, coreTenants map[int][]string) (*cpumgrx.CpuMgrx, error) {
+	restarted, rp, err := mgrx.Restart(params, pods)
+	if err != nil {
+		return nil, err
+	}
+	if rp.Refused != "" {
+		fmt.Printf("restart: refused: %s\n", rp.Refused)
+		return nil, nil
+	}
+	for _, asg := range rp.Kept {
+		fmt.Printf("restart: kept %s/%s: %s\n", asg.Pod, asg.Container, asg.After.String())
+	}
+	for _, asg := range rp.Changed {
+		fmt.Printf("restart: changed %s/%s: %s -> %s\n", asg.Pod, asg.Container, asg.Before.String(), asg.After.String())
+	}
+	for _, asg := range rp.Dropped {
+		fmt.Printf("restart: dropped %s/%s: %s\n", asg.Pod, asg.Container, asg.Before.String())
+	}
+	for _, rej := range rp.Rejected {
+		fmt.Printf("restart: %s: %s (cause: %s)\n", rej.Pod, rej.String(), rej.Cause)
+		for coreID, podNames := range coreTenants {
+			podNames = slices.DeleteFunc(podNames, func(name string) bool { return name == rej.Pod })
+			if len(podNames) == 0 {
+				delete(coreTenants, coreID)
+				continue
+			}
+			coreTenants[coreID] = podNames
+		}
+	}
+	return restarted, nil
+}
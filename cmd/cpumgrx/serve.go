@@ -0,0 +1,65 @@
+/*
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2026 Red Hat, Inc.
+ */
+
+package main
+
+import (
+	"net/http"
+	"os"
+
+	"github.com/spf13/pflag"
+	utilfeature "k8s.io/apiserver/pkg/util/feature"
+	"k8s.io/klog/v2"
+
+	"github.com/ffromani/cpumgrx/pkg/klogcapture"
+	"github.com/ffromani/cpumgrx/pkg/server"
+)
+
+const defaultListenAddress = "127.0.0.1:8765"
+
+// runServe handles `cpumgrx serve`. The arguments don't include the subcommand.
+func runServe(args []string) {
+	flags := pflag.NewFlagSet("serve", pflag.ExitOnError)
+	flags.AddFlagSet(pflag.CommandLine) // klog flags
+	var listenAddress string
+	var captureLog bool
+	var rawFeatureGates string
+	flags.StringVar(&listenAddress, "listen", defaultListenAddress, "address to serve the HTTP API on")
+	flags.BoolVar(&captureLog, "capture-log", false, "return what the kubelet managers log about each pod in the results, instead of logging it")
+	flags.StringVar(&rawFeatureGates, "feature-gates", "", "set feature gates for all the sessions, as comma-separated key=value pairs")
+	flags.Parse(args)
+
+	// feature gates are process-wide, so the sessions can only use the ones set here
+	if err := utilfeature.DefaultMutableFeatureGate.SetFromMap(mustParseFeatureGates(rawFeatureGates)); err != nil {
+		klog.Errorf("cannot set the feature gates: %v", err)
+		os.Exit(1)
+	}
+
+	if captureLog {
+		capture := klogcapture.Start(klogcapture.DefaultVerbosity)
+		defer capture.Stop()
+	}
+
+	srv := server.New()
+	defer srv.Close()
+
+	klog.Infof("serving on %q", listenAddress)
+	if err := http.ListenAndServe(listenAddress, srv.Handler()); err != nil {
+		klog.Errorf("serving failed: %v", err)
+		srv.Close()
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,390 @@
+/*
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2026 Red Hat, Inc.
+ */
+
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/uuid"
+	"k8s.io/klog/v2"
+	"k8s.io/kubernetes/pkg/kubelet/cm/cpumanager/topology"
+	"sigs.k8s.io/yaml"
+
+	"github.com/ffromani/cpumgrx/pkg/cpumgrx"
+	"github.com/ffromani/cpumgrx/pkg/podtemplate"
+	"github.com/ffromani/cpumgrx/pkg/workload"
+)
+
+const shellHelp = `commands:
+  add TEMPLATE...            admit template pods
+  add -f FILE                admit the pods found in FILE
+  rm NAME                    remove a pod, releasing its CPUs
+  show pods|free|grid        show the admitted pods, the free CPUs or the CPU grid
+  hints TEMPLATE             show the CPU topology hints of a template pod
+  undo                       revert the last add or rm
+  save FILE                  save the admitted pods as a workload manifest
+  help                       show this help
+  quit                       leave the shell
+`
+
+// gridSymbols mark the pods in the CPU grid, given in admission order
+const gridSymbols = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+var errQuit = errors.New("quit")
+
+// shellStep is what undo needs to bring back the state before an add or rm.
+type shellStep struct {
+	snap cpumgrx.Snapshot
+	pods []*v1.Pod
+}
+
+// shell explores the allocations step by step on a single manager.
+type shell struct {
+	params  cpumgrx.Params
+	topo    *topology.CPUTopology
+	mgrx    *cpumgrx.CpuMgrx
+	history []shellStep
+	// admitted pods, in admission order
+	pods []*v1.Pod
+	// symbols of the pods with exclusive CPUs in the CPU grid, kept once given
+	symbols    map[types.UID]byte
+	nextSymbol int
+}
+
+func runShell(params cpumgrx.Params, pods []*v1.Pod) {
+	topo, err := topology.Discover(params.MachineInfo)
+	if err != nil {
+		klog.Errorf("topology discovery failed: %v", err)
+		os.Exit(1)
+	}
+	mgrx, err := cpumgrx.NewFromParams(params)
+	if err != nil {
+		klog.Errorf("cpumanager creation failed: %v", err)
+		os.Exit(1)
+	}
+	defer mgrx.Close()
+	sh := &shell{
+		params:  params,
+		topo:    topo,
+		mgrx:    mgrx,
+		symbols: make(map[types.UID]byte),
+	}
+
+	if len(pods) > 0 {
+		if err := sh.add(pods); err != nil {
+			klog.Errorf("%v", err)
+			os.Exit(1)
+		}
+	}
+
+	interactive := isTerminal(os.Stdin)
+	scanner := bufio.NewScanner(os.Stdin)
+	for {
+		if interactive {
+			fmt.Print("cpumgrx> ")
+		}
+		if !scanner.Scan() {
+			break
+		}
+		err := sh.exec(strings.Fields(scanner.Text()))
+		if errors.Is(err, errQuit) {
+			return
+		}
+		if err != nil {
+			fmt.Printf("error: %v\n", err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		klog.Errorf("error reading commands: %v", err)
+	}
+}
+
+func (sh *shell) exec(args []string) error {
+	if len(args) == 0 {
+		return nil
+	}
+	cmd, args := args[0], args[1:]
+	switch cmd {
+	case "add":
+		pods, err := shellPods(args)
+		if err != nil {
+			return err
+		}
+		return sh.add(pods)
+	case "rm":
+		if len(args) != 1 {
+			return errors.New("usage: rm NAME")
+		}
+		return sh.remove(args[0])
+	case "show":
+		if len(args) != 1 {
+			return errors.New("usage: show pods|free|grid")
+		}
+		return sh.show(args[0])
+	case "hints":
+		if len(args) != 1 {
+			return errors.New("usage: hints TEMPLATE")
+		}
+		return sh.hints(args[0])
+	case "undo":
+		return sh.undo()
+	case "save":
+		if len(args) != 1 {
+			return errors.New("usage: save FILE")
+		}
+		return sh.save(args[0])
+	case "help":
+		fmt.Print(shellHelp)
+	case "quit", "exit":
+		return errQuit
+	default:
+		return fmt.Errorf("unknown command %q, try help", cmd)
+	}
+	return nil
+}
+
+// add admits the pods in order. Names must be unique, so nothing is admitted if any
+// of them is given twice or already admitted.
+func (sh *shell) add(pods []*v1.Pod) error {
+	names := make(map[string]bool)
+	for _, pod := range pods {
+		if sh.findPod(pod.Name) >= 0 {
+			return fmt.Errorf("pod %q already admitted", pod.Name)
+		}
+		if names[pod.Name] {
+			return fmt.Errorf("pod %q given more than once", pod.Name)
+		}
+		names[pod.Name] = true
+	}
+
+	cpuDetails := CPUDetails{sh.topo.CPUDetails}
+	step := sh.step()
+	for _, pod := range pods {
+		if res, err := sh.mgrx.Run(pod); err != nil {
+			fmt.Printf("%s: %s (cause: %s)\n", pod.Name, res.String(), res.Cause)
+			continue
+		}
+		sh.pods = append(sh.pods, pod)
+		if _, ok := sh.symbols[pod.UID]; !ok && !sh.mgrx.GetPodExclusiveCPUs(pod).IsEmpty() {
+			sh.symbols[pod.UID] = '*'
+			if sh.nextSymbol < len(gridSymbols) {
+				sh.symbols[pod.UID] = gridSymbols[sh.nextSymbol]
+				sh.nextSymbol++
+			}
+		}
+		cpus := sh.mgrx.GetCPUs(pod)
+		printCPUs(pod.Name, cpus, partitionCPUsByCore(cpus, cpuDetails))
+	}
+	// rejected pods leave the state as it was, nothing to undo
+	if len(sh.pods) > len(step.pods) {
+		sh.history = append(sh.history, step)
+	}
+	return nil
+}
+
+func (sh *shell) remove(podName string) error {
+	idx := sh.findPod(podName)
+	if idx < 0 {
+		return fmt.Errorf("pod %q not admitted", podName)
+	}
+	step := sh.step()
+	if err := sh.mgrx.Remove(sh.pods[idx]); err != nil {
+		return err
+	}
+	sh.history = append(sh.history, step)
+	sh.pods = append(sh.pods[:idx:idx], sh.pods[idx+1:]...)
+	return nil
+}
+
+// step records the current state, to get back to it on undo.
+func (sh *shell) step() shellStep {
+	return shellStep{
+		snap: sh.mgrx.Snapshot(),
+		pods: sh.pods,
+	}
+}
+
+func (sh *shell) undo() error {
+	if len(sh.history) == 0 {
+		return errors.New("nothing to undo")
+	}
+	last := sh.history[len(sh.history)-1]
+	if err := sh.mgrx.Restore(last.snap); err != nil {
+		return err
+	}
+	sh.pods = last.pods
+	sh.history = sh.history[:len(sh.history)-1]
+	return nil
+}
+
+func (sh *shell) show(what string) error {
+	switch what {
+	case "pods":
+		cpuDetails := CPUDetails{sh.topo.CPUDetails}
+		for _, pod := range sh.pods {
+			cpus := sh.mgrx.GetCPUs(pod)
+			printCPUs(pod.Name, cpus, partitionCPUsByCore(cpus, cpuDetails))
+		}
+	case "free":
+		free := sh.mgrx.GetFreeCPUs()
+		fmt.Printf("free: %s (%d CPUs)\n", free.String(), free.Size())
+		for _, numaID := range sh.topo.CPUDetails.NUMANodes().List() {
+			numaFree := free.Intersection(sh.topo.CPUDetails.CPUsInNUMANodes(numaID))
+			fmt.Printf("numa %d: %s (%d CPUs)\n", numaID, numaFree.String(), numaFree.Size())
+		}
+	case "grid":
+		sh.printGrid()
+	default:
+		return fmt.Errorf("unknown item %q, expected pods, free or grid", what)
+	}
+	return nil
+}
+
+// printGrid prints a line per NUMA node, and a group of CPUs per physical core.
+// Free CPUs are marked with '.', reserved ones with '#', and the exclusive CPUs
+// with the pod symbol. CPUs running shared pods only are free.
+func (sh *shell) printGrid() {
+	owners := make(map[int]byte)
+	for _, cpuID := range sh.params.ReservedCPUSet.List() {
+		owners[cpuID] = '#'
+	}
+	var legend []string
+	for _, pod := range sh.pods {
+		cpus := sh.mgrx.GetPodExclusiveCPUs(pod)
+		if cpus.IsEmpty() {
+			continue
+		}
+		symbol := sh.symbols[pod.UID]
+		for _, cpuID := range cpus.List() {
+			owners[cpuID] = symbol
+		}
+		legend = append(legend, fmt.Sprintf("%c=%s", symbol, pod.Name))
+	}
+
+	details := sh.topo.CPUDetails
+	for _, numaID := range details.NUMANodes().List() {
+		var cores []string
+		for _, coreID := range details.CoresInNUMANodes(numaID).List() {
+			var b strings.Builder
+			for _, cpuID := range details.CPUsInCores(coreID).List() {
+				symbol, ok := owners[cpuID]
+				if !ok {
+					symbol = '.'
+				}
+				b.WriteByte(symbol)
+			}
+			cores = append(cores, b.String())
+		}
+		fmt.Printf("numa %d: %s\n", numaID, strings.Join(cores, " "))
+	}
+	if len(legend) > 0 {
+		fmt.Printf("%s\n", strings.Join(legend, " "))
+	}
+}
+
+func (sh *shell) hints(arg string) error {
+	pods, err := podtemplate.Parse(arg)
+	if err != nil {
+		return err
+	}
+	if len(pods) != 1 {
+		return fmt.Errorf("bad pod template %q: expected a single pod", arg)
+	}
+	pod := pods[0]
+	hints := sh.mgrx.GetTopologyHints(pod)
+	for _, hint := range hints["cpu"] {
+		fmt.Printf("\tmask=[%6s] preferred=%t\n", hint.NUMANodeAffinity, hint.Preferred)
+	}
+	return nil
+}
+
+// save writes the admitted pods in admission order, in a format the workload files accept.
+// Replaying them could give different allocations if pods were removed meanwhile.
+func (sh *shell) save(path string) error {
+	var b strings.Builder
+	for _, pod := range sh.pods {
+		pod = pod.DeepCopy()
+		pod.APIVersion = "v1"
+		pod.Kind = "Pod"
+		// let the pods get new UIDs once read back
+		pod.UID = ""
+		data, err := yaml.Marshal(pod)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(&b, "---\n%s", data)
+	}
+	return os.WriteFile(path, []byte(b.String()), 0644)
+}
+
+// findPod looks up the pod by name; template pods can be given by template name too.
+func (sh *shell) findPod(name string) int {
+	for _, podName := range []string{name, name + "-pod"} {
+		for idx, pod := range sh.pods {
+			if pod.Name == podName {
+				return idx
+			}
+		}
+	}
+	return -1
+}
+
+func shellPods(args []string) ([]*v1.Pod, error) {
+	if len(args) == 0 {
+		return nil, errors.New("usage: add TEMPLATE... or add -f FILE")
+	}
+	var pods []*v1.Pod
+	if args[0] == "-f" {
+		if len(args) != 2 {
+			return nil, errors.New("usage: add -f FILE")
+		}
+		var err error
+		pods, err = workload.ReadFile(args[1])
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		var err error
+		pods, err = podtemplate.ParseAll(args)
+		if err != nil {
+			return nil, err
+		}
+	}
+	for _, pod := range pods {
+		if len(pod.Spec.Containers) == 0 {
+			return nil, fmt.Errorf("pod %q: no containers", pod.Name)
+		}
+		if pod.UID == "" {
+			pod.UID = uuid.NewUUID()
+		}
+	}
+	return pods, nil
+}
+
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
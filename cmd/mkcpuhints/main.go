@@ -21,19 +21,18 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
-	"regexp"
 
 	"flag"
 
 	cadvisorapi "github.com/google/cadvisor/info/v1"
 	"github.com/spf13/pflag"
 
-	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	"k8s.io/klog/v2"
 	"k8s.io/utils/cpuset"
 
 	"github.com/ffromani/cpumgrx/pkg/cpumgrx"
+	"github.com/ffromani/cpumgrx/pkg/podtemplate"
 )
 
 func main() {
@@ -74,11 +73,10 @@ func main() {
 		MachineInfo:        mustReadMachineInfo(machineInfoPath),
 	}
 
-	var pods []*v1.Pod
-
-	cpuReqs := parseCpuReqs(args)
-	for _, cpuReq := range cpuReqs {
-		pods = append(pods, makePod(cpuReq))
+	pods, err := podtemplate.ParseAll(args)
+	if err != nil {
+		klog.Errorf("%v", err)
+		os.Exit(1)
 	}
 
 	mgrx, err := cpumgrx.NewFromParams(params)
@@ -105,55 +103,6 @@ func main() {
 	}
 }
 
-type cpuReqSpec struct {
-	Name     string
-	Limits   resource.Quantity
-	Requests resource.Quantity
-}
-
-// name=request/limit
-func parseCpuReqs(args []string) []cpuReqSpec {
-	var reqsRE = regexp.MustCompile(`^(\S*)=(\S*)/(\S*)$`)
-	var cpuReqs []cpuReqSpec
-	for _, arg := range args {
-		items := reqsRE.FindAllStringSubmatch(arg, -1)
-		// items[0] is the full match
-		if items == nil || len(items[0]) != 4 {
-			klog.Warningf("cannot parse cpu req spec %q - skipped", arg)
-			continue
-		}
-		cpuReqs = append(cpuReqs, cpuReqSpec{
-			Name:     items[0][1],
-			Limits:   resource.MustParse(items[0][2]),
-			Requests: resource.MustParse(items[0][3]),
-		})
-	}
-	return cpuReqs
-}
-
-func makePod(cpuReq cpuReqSpec) *v1.Pod {
-	pod := v1.Pod{
-		Spec: v1.PodSpec{
-			Containers: []v1.Container{
-				v1.Container{
-					Resources: v1.ResourceRequirements{
-						Limits:   make(v1.ResourceList),
-						Requests: make(v1.ResourceList),
-					},
-				},
-			},
-		},
-	}
-	// yep, that's the lazy way
-	pod.Name = fmt.Sprintf("%s-pod", cpuReq.Name)
-	pod.Spec.Containers[0].Name = fmt.Sprintf("%s-cnt", cpuReq.Name)
-	pod.Spec.Containers[0].Resources.Requests[v1.ResourceCPU] = cpuReq.Requests
-	pod.Spec.Containers[0].Resources.Limits[v1.ResourceCPU] = cpuReq.Limits
-	pod.Spec.Containers[0].Resources.Requests[v1.ResourceMemory] = resource.MustParse("1Gi")
-	pod.Spec.Containers[0].Resources.Limits[v1.ResourceMemory] = resource.MustParse("1Gi")
-	return &pod
-}
-
 func mustParseCPUSet(rawCPUs string) cpuset.CPUSet {
 	cpus, err := cpuset.Parse(rawCPUs)
 	if err != nil {
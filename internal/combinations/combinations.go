@@ -0,0 +1,45 @@
+/*
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2026 Red Hat, Inc.
+ */
+
+package combinations
+
+// Visit calls fn with all the k-combinations of [0, n) in lexicographic order,
+// until fn returns false. fn must not retain nor modify idxs.
+func Visit(n, k int, fn func(idxs []int) bool) {
+	if k <= 0 || k > n {
+		return
+	}
+	idxs := make([]int, k)
+	for i := range idxs {
+		idxs[i] = i
+	}
+	for {
+		if !fn(idxs) {
+			return
+		}
+		i := k - 1
+		for i >= 0 && idxs[i] == n-k+i {
+			i--
+		}
+		if i < 0 {
+			return
+		}
+		idxs[i]++
+		for j := i + 1; j < k; j++ {
+			idxs[j] = idxs[j-1] + 1
+		}
+	}
+}
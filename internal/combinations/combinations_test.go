@@ -0,0 +1,70 @@
+/*
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2026 Red Hat, Inc.
+ */
+
+package combinations
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestVisit(t *testing.T) {
+	testCases := []struct {
+		name     string
+		n        int
+		k        int
+		limit    int
+		expected [][]int
+	}{
+		{
+			name:     "pairs",
+			n:        4,
+			k:        2,
+			expected: [][]int{{0, 1}, {0, 2}, {0, 3}, {1, 2}, {1, 3}, {2, 3}},
+		},
+		{
+			name:     "all",
+			n:        3,
+			k:        3,
+			expected: [][]int{{0, 1, 2}},
+		},
+		{
+			name: "too many",
+			n:    2,
+			k:    3,
+		},
+		{
+			name:     "stop early",
+			n:        4,
+			k:        1,
+			limit:    2,
+			expected: [][]int{{0}, {1}},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var got [][]int
+			Visit(tc.n, tc.k, func(idxs []int) bool {
+				got = append(got, append([]int{}, idxs...))
+				return tc.limit == 0 || len(got) < tc.limit
+			})
+			if !reflect.DeepEqual(got, tc.expected) {
+				t.Errorf("got %v expected %v", got, tc.expected)
+			}
+		})
+	}
+}
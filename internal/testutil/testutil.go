@@ -0,0 +1,102 @@
+/*
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2026 Red Hat, Inc.
+ */
+
+// Package testutil holds the helpers shared by the tests of the other packages.
+package testutil
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"testing"
+
+	cadvisorapi "github.com/google/cadvisor/info/v1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/kubernetes/pkg/kubelet/cm/cpumanager/topology"
+)
+
+// ReadMachineInfo loads a machine info JSON, like the ones in the examples.
+func ReadMachineInfo(t *testing.T, path string) *cadvisorapi.MachineInfo {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("cannot read %q: %v", path, err)
+	}
+	var machineInfo cadvisorapi.MachineInfo
+	if err := json.Unmarshal(data, &machineInfo); err != nil {
+		t.Fatalf("cannot decode %q: %v", path, err)
+	}
+	return &machineInfo
+}
+
+// Discover returns the CPU topology of the machine info JSON.
+func Discover(t *testing.T, path string) *topology.CPUTopology {
+	t.Helper()
+	topo, err := topology.Discover(ReadMachineInfo(t, path))
+	if err != nil {
+		t.Fatalf("topology discovery failed: %v", err)
+	}
+	return topo
+}
+
+// MakeTopology returns a 16 CPUs machine with 2 NUMA nodes, 4 cores per NUMA node and
+// 2 threads per core: cpus 0-3 + 8-11 on NUMA 0, 4-7 + 12-15 on NUMA 1. Each uncore
+// cache is shared by coresPerUncore cores.
+func MakeTopology(coresPerUncore int) *topology.CPUTopology {
+	details := make(topology.CPUDetails)
+	for cpuID := 0; cpuID < 16; cpuID++ {
+		coreID := cpuID % 8
+		numaID := coreID / 4
+		details[cpuID] = topology.CPUInfo{
+			NUMANodeID:    numaID,
+			SocketID:      numaID,
+			CoreID:        coreID,
+			UncoreCacheID: coreID / coresPerUncore,
+		}
+	}
+	return &topology.CPUTopology{
+		NumCPUs:        16,
+		NumCores:       8,
+		NumUncoreCache: 8 / coresPerUncore,
+		NumSockets:     2,
+		NumNUMANodes:   2,
+		CPUDetails:     details,
+	}
+}
+
+// MakePod returns a guaranteed pod with a container for each amount of CPUs,
+// named cnt-0, cnt-1 and so on.
+func MakePod(name string, cpus ...string) *v1.Pod {
+	pod := v1.Pod{}
+	pod.Name = name
+	pod.UID = types.UID(name + "-uid")
+	for idx, amount := range cpus {
+		res := v1.ResourceList{
+			v1.ResourceCPU:    resource.MustParse(amount),
+			v1.ResourceMemory: resource.MustParse("1Gi"),
+		}
+		pod.Spec.Containers = append(pod.Spec.Containers, v1.Container{
+			Name: fmt.Sprintf("cnt-%d", idx),
+			Resources: v1.ResourceRequirements{
+				Limits:   res,
+				Requests: res.DeepCopy(),
+			},
+		})
+	}
+	return &pod
+}
@@ -0,0 +1,107 @@
+/*
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2026 Red Hat, Inc.
+ */
+
+package alignment
+
+import (
+	"fmt"
+
+	"k8s.io/kubernetes/pkg/kubelet/cm/cpumanager/topology"
+	"k8s.io/utils/cpuset"
+)
+
+// Info describes how a set of CPUs maps onto the machine topology.
+type Info struct {
+	CPUs         int `json:"cpus"`
+	NUMANodes    int `json:"numaNodes"`
+	Sockets      int `json:"sockets"`
+	UncoreCaches int `json:"uncoreCaches"`
+	// PartialCores is the number of physical cores which are only partially
+	// covered by the CPU set, hence shared with someone else.
+	PartialCores int `json:"partialCores"`
+}
+
+func (info Info) String() string {
+	return fmt.Sprintf("cpus=%d numa=%d sockets=%d uncore=%d partialCores=%d", info.CPUs, info.NUMANodes, info.Sockets, info.UncoreCaches, info.PartialCores)
+}
+
+// Describe computes the Info of the given CPU set.
+func Describe(topo *topology.CPUTopology, cpus cpuset.CPUSet) Info {
+	details := topo.CPUDetails.KeepOnly(cpus)
+	info := Info{
+		CPUs:         cpus.Size(),
+		NUMANodes:    details.NUMANodes().Size(),
+		Sockets:      details.Sockets().Size(),
+		UncoreCaches: details.UncoreCaches().Size(),
+	}
+	for _, coreID := range details.Cores().UnsortedList() {
+		if !topo.CPUDetails.CPUsInCores(coreID).IsSubsetOf(cpus) {
+			info.PartialCores++
+		}
+	}
+	return info
+}
+
+// Best computes the Info of the best possible allocation of the given
+// amount of CPUs on an empty machine having the given topology.
+func Best(topo *topology.CPUTopology, numCPUs int) Info {
+	if numCPUs <= 0 {
+		return Info{}
+	}
+	info := Info{
+		CPUs:         numCPUs,
+		NUMANodes:    spanOf(numCPUs, perGroup(topo.NumCPUs, topo.NumNUMANodes)),
+		Sockets:      spanOf(numCPUs, topo.CPUsPerSocket()),
+		UncoreCaches: spanOf(numCPUs, topo.CPUsPerUncore()),
+	}
+	if cpusPerCore := topo.CPUsPerCore(); cpusPerCore > 0 && numCPUs%cpusPerCore != 0 {
+		info.PartialCores = 1
+	}
+	return info
+}
+
+// IsAligned tells if the given CPU set is as well aligned as the best
+// possible allocation of the same size. Uncore caches are not considered,
+// because the kubelet aligns to them only on a best-effort basis.
+func IsAligned(topo *topology.CPUTopology, cpus cpuset.CPUSet) bool {
+	got := Describe(topo, cpus)
+	best := Best(topo, cpus.Size())
+	return got.NUMANodes <= best.NUMANodes && got.PartialCores <= best.PartialCores
+}
+
+// Penalty tells how much worse `got` is compared to `best`; zero means
+// it is as good. Spanning extra NUMA nodes always weights more than
+// splitting extra cores, which always weights more than spanning extra
+// uncore caches.
+func Penalty(got, best Info) int {
+	return 10000*max(got.NUMANodes-best.NUMANodes, 0) +
+		100*max(got.PartialCores-best.PartialCores, 0) +
+		max(got.UncoreCaches-best.UncoreCaches, 0)
+}
+
+func perGroup(total, groups int) int {
+	if groups == 0 {
+		return 0
+	}
+	return total / groups
+}
+
+func spanOf(numCPUs, groupSize int) int {
+	if groupSize == 0 {
+		return 0
+	}
+	return (numCPUs + groupSize - 1) / groupSize
+}
@@ -0,0 +1,103 @@
+/*
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2026 Red Hat, Inc.
+ */
+
+package alignment
+
+import (
+	"testing"
+
+	"k8s.io/utils/cpuset"
+
+	"github.com/ffromani/cpumgrx/internal/testutil"
+)
+
+func TestDescribe(t *testing.T) {
+	topo := testutil.MakeTopology(4)
+	tests := []struct {
+		name     string
+		cpus     cpuset.CPUSet
+		expected Info
+	}{
+		{
+			name:     "full cores single numa",
+			cpus:     cpuset.New(0, 1, 8, 9),
+			expected: Info{CPUs: 4, NUMANodes: 1, Sockets: 1, UncoreCaches: 1},
+		},
+		{
+			name:     "split cores single numa",
+			cpus:     cpuset.New(0, 1, 2, 3),
+			expected: Info{CPUs: 4, NUMANodes: 1, Sockets: 1, UncoreCaches: 1, PartialCores: 4},
+		},
+		{
+			name:     "full cores across numa",
+			cpus:     cpuset.New(3, 4, 11, 12),
+			expected: Info{CPUs: 4, NUMANodes: 2, Sockets: 2, UncoreCaches: 2},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Describe(topo, tt.cpus)
+			if got != tt.expected {
+				t.Errorf("got=%v expected=%v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestIsAligned(t *testing.T) {
+	topo := testutil.MakeTopology(4)
+	tests := []struct {
+		name     string
+		cpus     cpuset.CPUSet
+		expected bool
+	}{
+		{
+			name:     "full cores single numa",
+			cpus:     cpuset.New(0, 1, 8, 9),
+			expected: true,
+		},
+		{
+			name:     "odd request, one partial core is unavoidable",
+			cpus:     cpuset.New(0, 8, 1),
+			expected: true,
+		},
+		{
+			name:     "split cores",
+			cpus:     cpuset.New(0, 1, 2, 3),
+			expected: false,
+		},
+		{
+			name:     "across numa when one is enough",
+			cpus:     cpuset.New(3, 4, 11, 12),
+			expected: false,
+		},
+		{
+			name:     "across numa when one is not enough",
+			cpus:     cpuset.New(0, 1, 2, 3, 4, 8, 9, 10, 11, 12),
+			expected: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := IsAligned(topo, tt.cpus)
+			if got != tt.expected {
+				t.Errorf("got=%v expected=%v (%s)", got, tt.expected, Describe(topo, tt.cpus))
+			}
+		})
+	}
+}
@@ -0,0 +1,354 @@
+/*
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2026 Red Hat, Inc.
+ */
+
+// Package audit checks a cpu_manager_state checkpoint taken from a node against the node topology
+// and settings. It finds what makes the kubelet refuse to start, like a corrupt checkpoint or a
+// default set which doesn't match the machine, and what breaks the guarantees of the exclusive
+// CPUs without the kubelet noticing, like overlapping assignments.
+package audit
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+
+	cperrors "k8s.io/kubernetes/pkg/kubelet/checkpointmanager/errors"
+	"k8s.io/kubernetes/pkg/kubelet/cm/cpumanager/state"
+	"k8s.io/kubernetes/pkg/kubelet/cm/cpumanager/topology"
+	"k8s.io/utils/cpuset"
+)
+
+// Format is the layout of the checkpoint entries.
+type Format string
+
+const (
+	// FormatV1 keys the entries by container ID
+	FormatV1 Format = "v1"
+	// FormatV2 keys the entries by pod UID, then container name
+	FormatV2 Format = "v2"
+)
+
+// Problem is a kind of flaw of a checkpoint.
+type Problem string
+
+const (
+	// ProblemCorrupt means the checksum doesn't match the content
+	ProblemCorrupt Problem = "corrupt checkpoint"
+	// ProblemMalformed means a CPU set can't be parsed
+	ProblemMalformed Problem = "malformed CPU set"
+	// ProblemPolicy means the checkpoint was written by another policy
+	ProblemPolicy Problem = "policy mismatch"
+	// ProblemUnknownCPUs means the checkpoint holds CPUs the machine doesn't have
+	ProblemUnknownCPUs Problem = "unknown CPUs"
+	// ProblemDefaultSet means the default set doesn't fit with the assignments or the reserved CPUs
+	ProblemDefaultSet Problem = "default set inconsistent"
+	// ProblemReservedAssigned means reserved CPUs are assigned exclusively to containers
+	ProblemReservedAssigned Problem = "reserved CPUs assigned"
+	// ProblemOverlap means CPUs are assigned exclusively to containers of different pods
+	ProblemOverlap Problem = "overlapping assignments"
+	// ProblemHalfCores means a container got only some threads of a core under full-pcpus-only
+	ProblemHalfCores Problem = "half cores"
+)
+
+// Issue is a flaw found in a checkpoint.
+type Issue struct {
+	Problem Problem `json:"problem"`
+	Message string  `json:"message"`
+	// CPUs are the CPUs involved, if any
+	CPUs cpuset.CPUSet `json:"cpus"`
+	// Fatal means the kubelet refuses to start with this checkpoint
+	Fatal bool `json:"fatal"`
+}
+
+func (is Issue) String() string {
+	return string(is.Problem) + ": " + is.Message
+}
+
+// Entry is a CPU assignment found in a checkpoint.
+type Entry struct {
+	// PodUID is empty in the v1 format, and Container holds the container ID
+	PodUID    string        `json:"podUID,omitempty"`
+	Container string        `json:"container"`
+	CPUs      cpuset.CPUSet `json:"cpus"`
+}
+
+// Name identifies the container the CPUs are assigned to.
+func (ent Entry) Name() string {
+	if ent.PodUID == "" {
+		return ent.Container
+	}
+	return ent.PodUID + "/" + ent.Container
+}
+
+// Checkpoint is the content of a cpu_manager_state file.
+type Checkpoint struct {
+	Format        Format        `json:"format"`
+	PolicyName    string        `json:"policyName"`
+	DefaultCPUSet cpuset.CPUSet `json:"defaultCpuSet"`
+	// Entries are sorted by pod UID, then container
+	Entries []Entry `json:"entries,omitempty"`
+	// Checksum is zero if the file has none, which the kubelet accepts
+	Checksum uint64 `json:"checksum"`
+}
+
+// Options are the node settings the checkpoint is checked against.
+type Options struct {
+	// PolicyName is the configured cpu manager policy, if not empty
+	PolicyName    string
+	ReservedCPUs  cpuset.CPUSet
+	FullPCPUsOnly bool
+	// StrictCPUReservation keeps the reserved CPUs out of the default set
+	StrictCPUReservation bool
+}
+
+// Report is what the audit of a checkpoint found.
+type Report struct {
+	Checkpoint Checkpoint `json:"checkpoint"`
+	// Issues are sorted fatal first, in the order they are checked
+	Issues []Issue `json:"issues,omitempty"`
+}
+
+// Fatal tells if the kubelet refuses to start with the checkpoint.
+func (rp Report) Fatal() bool {
+	return len(rp.Issues) > 0 && rp.Issues[0].Fatal
+}
+
+// Audit checks the content of a cpu_manager_state file against the node topology and settings.
+// It fails only if the data is not a checkpoint at all.
+func Audit(data []byte, topo *topology.CPUTopology, opts Options) (Report, error) {
+	cp, issues, err := load(data)
+	if err != nil {
+		return Report{}, err
+	}
+	issues = append(issues, check(cp, topo, opts)...)
+	sort.SliceStable(issues, func(i, j int) bool {
+		return issues[i].Fatal && !issues[j].Fatal
+	})
+	return Report{Checkpoint: cp, Issues: issues}, nil
+}
+
+// load decodes the checkpoint in the format its entries have, and verifies the checksum of that format.
+func load(data []byte) (Checkpoint, []Issue, error) {
+	var issues []Issue
+	cpV1 := &state.CPUManagerCheckpointV1{}
+	errV1 := json.Unmarshal(data, cpV1)
+	cpV2 := state.NewCPUManagerCheckpoint()
+	errV2 := json.Unmarshal(data, cpV2)
+
+	var cp Checkpoint
+	var checksumErr error
+	switch {
+	case errV1 != nil && errV2 != nil:
+		return cp, nil, fmt.Errorf("not a cpu manager checkpoint: %w", errV2)
+	case errV2 != nil:
+		cp = Checkpoint{Format: FormatV1, PolicyName: cpV1.PolicyName, Checksum: uint64(cpV1.Checksum)}
+		checksumErr = cpV1.VerifyChecksum()
+		for cntID, cpus := range cpV1.Entries {
+			issues = appendEntry(&cp, issues, "", cntID, cpus)
+		}
+	default:
+		// without entries both formats decode, and the kubelet takes the one whose checksum matches
+		cp = Checkpoint{Format: FormatV2, PolicyName: cpV2.PolicyName, Checksum: uint64(cpV2.Checksum)}
+		checksumErr = cpV2.VerifyChecksum()
+		if checksumErr != nil && errV1 == nil && cpV1.VerifyChecksum() == nil {
+			cp.Format, checksumErr = FormatV1, nil
+		}
+		for podUID, cnts := range cpV2.Entries {
+			for cntName, cpus := range cnts {
+				issues = appendEntry(&cp, issues, podUID, cntName, cpus)
+			}
+		}
+	}
+	sort.Slice(cp.Entries, func(i, j int) bool {
+		if cp.Entries[i].PodUID != cp.Entries[j].PodUID {
+			return cp.Entries[i].PodUID < cp.Entries[j].PodUID
+		}
+		return cp.Entries[i].Container < cp.Entries[j].Container
+	})
+
+	if checksumErr != nil {
+		msg := checksumErr.Error()
+		var corruptErr *cperrors.CorruptCheckpointError
+		if errors.As(checksumErr, &corruptErr) {
+			msg = fmt.Sprintf("checksum %d, computed %d: the file was edited", corruptErr.ExpectedCS, corruptErr.ActualCS)
+		}
+		issues = append(issues, Issue{
+			Problem: ProblemCorrupt,
+			Message: msg,
+			Fatal:   true,
+		})
+	}
+
+	rawDefault := cpV2.DefaultCPUSet
+	if errV2 != nil {
+		rawDefault = cpV1.DefaultCPUSet
+	}
+	defaultCPUs, err := cpuset.Parse(rawDefault)
+	if err != nil {
+		issues = append(issues, Issue{
+			Problem: ProblemMalformed,
+			Message: fmt.Sprintf("default set %q: %v", rawDefault, err),
+			Fatal:   true,
+		})
+	}
+	cp.DefaultCPUSet = defaultCPUs
+	return cp, issues, nil
+}
+
+func appendEntry(cp *Checkpoint, issues []Issue, podUID, cntName, rawCPUs string) []Issue {
+	ent := Entry{PodUID: podUID, Container: cntName}
+	cpus, err := cpuset.Parse(rawCPUs)
+	if err != nil {
+		return append(issues, Issue{
+			Problem: ProblemMalformed,
+			Message: fmt.Sprintf("%s: %q: %v", ent.Name(), rawCPUs, err),
+			Fatal:   true,
+		})
+	}
+	ent.CPUs = cpus
+	cp.Entries = append(cp.Entries, ent)
+	return issues
+}
+
+// check mirrors the validation of the static policy, then looks for what the kubelet doesn't check.
+func check(cp Checkpoint, topo *topology.CPUTopology, opts Options) []Issue {
+	var issues []Issue
+	if opts.PolicyName != "" && opts.PolicyName != cp.PolicyName {
+		issues = append(issues, Issue{
+			Problem: ProblemPolicy,
+			Message: fmt.Sprintf("configured policy %q, checkpoint written by %q", opts.PolicyName, cp.PolicyName),
+			Fatal:   true,
+		})
+	}
+	// the none policy keeps no state worth checking
+	if cp.PolicyName != "static" {
+		return issues
+	}
+	if cp.DefaultCPUSet.IsEmpty() && len(cp.Entries) == 0 {
+		// the kubelet initializes the state
+		return issues
+	}
+
+	details := topo.CPUDetails
+	var assigned cpuset.CPUSet
+	for _, ent := range cp.Entries {
+		assigned = assigned.Union(ent.CPUs)
+	}
+
+	if unknown := cp.DefaultCPUSet.Union(assigned).Difference(details.CPUs()); !unknown.IsEmpty() {
+		issues = append(issues, Issue{
+			Problem: ProblemUnknownCPUs,
+			Message: fmt.Sprintf("CPUs %q are not on the machine, maybe offlined", unknown.String()),
+			CPUs:    unknown,
+			Fatal:   true,
+		})
+	}
+
+	if cp.DefaultCPUSet.IsEmpty() {
+		issues = append(issues, Issue{
+			Problem: ProblemDefaultSet,
+			Message: "empty with exclusive assignments",
+			Fatal:   true,
+		})
+	}
+	for _, ent := range cp.Entries {
+		if both := cp.DefaultCPUSet.Intersection(ent.CPUs); !both.IsEmpty() {
+			issues = append(issues, Issue{
+				Problem: ProblemDefaultSet,
+				Message: fmt.Sprintf("CPUs %q of %s are also in the default set", both.String(), ent.Name()),
+				CPUs:    both,
+				Fatal:   true,
+			})
+		}
+	}
+	expected := details.CPUs()
+	if opts.StrictCPUReservation {
+		expected = expected.Difference(opts.ReservedCPUs)
+	}
+	if lost := expected.Difference(cp.DefaultCPUSet.Union(assigned)); !lost.IsEmpty() {
+		issues = append(issues, Issue{
+			Problem: ProblemDefaultSet,
+			Message: fmt.Sprintf("CPUs %q are neither in the default set nor assigned", lost.String()),
+			CPUs:    lost,
+			Fatal:   true,
+		})
+	}
+	if opts.StrictCPUReservation {
+		if both := opts.ReservedCPUs.Intersection(cp.DefaultCPUSet); !both.IsEmpty() {
+			issues = append(issues, Issue{
+				Problem: ProblemDefaultSet,
+				Message: fmt.Sprintf("strictly reserved CPUs %q are in the default set", both.String()),
+				CPUs:    both,
+				Fatal:   true,
+			})
+		}
+	} else if missing := opts.ReservedCPUs.Difference(cp.DefaultCPUSet); !missing.IsEmpty() {
+		issues = append(issues, Issue{
+			Problem: ProblemDefaultSet,
+			Message: fmt.Sprintf("reserved CPUs %q are not in the default set", missing.String()),
+			CPUs:    missing,
+			Fatal:   true,
+		})
+	}
+
+	for _, ent := range cp.Entries {
+		if both := opts.ReservedCPUs.Intersection(ent.CPUs); !both.IsEmpty() {
+			issues = append(issues, Issue{
+				Problem: ProblemReservedAssigned,
+				Message: fmt.Sprintf("reserved CPUs %q are assigned to %s", both.String(), ent.Name()),
+				CPUs:    both,
+				Fatal:   true,
+			})
+		}
+	}
+
+	// the app containers reuse the CPUs of the init containers of their pod, so only
+	// overlaps across pods matter. The v1 entries don't tell the pods apart.
+	for i := range cp.Entries {
+		for j := i + 1; j < len(cp.Entries); j++ {
+			a, b := cp.Entries[i], cp.Entries[j]
+			if a.PodUID != "" && a.PodUID == b.PodUID {
+				continue
+			}
+			if both := a.CPUs.Intersection(b.CPUs); !both.IsEmpty() {
+				issues = append(issues, Issue{
+					Problem: ProblemOverlap,
+					Message: fmt.Sprintf("CPUs %q are assigned to both %s and %s", both.String(), a.Name(), b.Name()),
+					CPUs:    both,
+				})
+			}
+		}
+	}
+
+	if opts.FullPCPUsOnly {
+		for _, ent := range cp.Entries {
+			cpus := ent.CPUs.Intersection(details.CPUs())
+			for _, coreID := range details.KeepOnly(cpus).Cores().List() {
+				siblings := details.CPUsInCores(coreID).Difference(cpus)
+				if siblings.IsEmpty() {
+					continue
+				}
+				issues = append(issues, Issue{
+					Problem: ProblemHalfCores,
+					Message: fmt.Sprintf("%s has only some threads of core %d, not %q", ent.Name(), coreID, siblings.String()),
+					CPUs:    details.CPUsInCores(coreID).Intersection(cpus),
+				})
+			}
+		}
+	}
+	return issues
+}
@@ -0,0 +1,187 @@
+/*
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2026 Red Hat, Inc.
+ */
+
+package audit
+
+import (
+	"reflect"
+	"testing"
+
+	"k8s.io/kubernetes/pkg/kubelet/cm/cpumanager/state"
+	"k8s.io/utils/cpuset"
+
+	"github.com/ffromani/cpumgrx/internal/testutil"
+)
+
+func TestAudit(t *testing.T) {
+	ryzen := testutil.Discover(t, "../../examples/machineinfo-v49-ryzen5950x.json")
+
+	testCases := []struct {
+		name          string
+		data          []byte
+		opts          Options
+		expectedFmt   Format
+		expected      []Problem
+		expectedFatal bool
+	}{
+		{
+			name: "clean",
+			data: checkpointV2(t, "static", "0,3-16,19-31", map[string]map[string]string{
+				"pod-a": {"cnt": "1-2,17-18"},
+			}),
+			opts:        Options{PolicyName: "static", ReservedCPUs: cpuset.New(0, 16), FullPCPUsOnly: true},
+			expectedFmt: FormatV2,
+		},
+		{
+			name:        "fresh",
+			data:        checkpointV2(t, "static", "", nil),
+			opts:        Options{PolicyName: "static", ReservedCPUs: cpuset.New(0)},
+			expectedFmt: FormatV2,
+		},
+		{
+			name:        "v1",
+			data:        []byte(`{"policyName":"static","defaultCpuSet":"0,3-31","entries":{"cnt-id":"1-2"},"checksum":0}`),
+			opts:        Options{ReservedCPUs: cpuset.New(0)},
+			expectedFmt: FormatV1,
+		},
+		{
+			name:          "corrupt",
+			data:          []byte(`{"policyName":"static","defaultCpuSet":"0-31","checksum":42}`),
+			opts:          Options{ReservedCPUs: cpuset.New(0)},
+			expectedFmt:   FormatV2,
+			expected:      []Problem{ProblemCorrupt},
+			expectedFatal: true,
+		},
+		{
+			name: "policy changed",
+			data: checkpointV2(t, "static", "0-31", nil),
+			opts: Options{PolicyName: "none"},
+			// the checkpoint is otherwise fine
+			expectedFmt:   FormatV2,
+			expected:      []Problem{ProblemPolicy},
+			expectedFatal: true,
+		},
+		{
+			name: "offlined CPU",
+			data: checkpointV2(t, "static", "0,3-16,19-32", map[string]map[string]string{
+				"pod-a": {"cnt": "1-2,17-18"},
+			}),
+			opts:          Options{ReservedCPUs: cpuset.New(0)},
+			expectedFmt:   FormatV2,
+			expected:      []Problem{ProblemUnknownCPUs},
+			expectedFatal: true,
+		},
+		{
+			name: "reserved CPUs assigned",
+			data: checkpointV2(t, "static", "3-16,19-31", map[string]map[string]string{
+				"pod-a": {"cnt": "0-2,17-18"},
+			}),
+			opts:          Options{ReservedCPUs: cpuset.New(0)},
+			expectedFmt:   FormatV2,
+			expected:      []Problem{ProblemDefaultSet, ProblemReservedAssigned},
+			expectedFatal: true,
+		},
+		{
+			name: "lost CPUs",
+			data: checkpointV2(t, "static", "0,3-16,19-30", map[string]map[string]string{
+				"pod-a": {"cnt": "1-2,17-18"},
+			}),
+			opts:          Options{ReservedCPUs: cpuset.New(0)},
+			expectedFmt:   FormatV2,
+			expected:      []Problem{ProblemDefaultSet},
+			expectedFatal: true,
+		},
+		{
+			name: "overlap across pods",
+			data: checkpointV2(t, "static", "0,3-16,19-31", map[string]map[string]string{
+				"pod-a": {"cnt": "1-2,17-18"},
+				"pod-b": {"cnt": "2,18"},
+			}),
+			opts:        Options{ReservedCPUs: cpuset.New(0)},
+			expectedFmt: FormatV2,
+			expected:    []Problem{ProblemOverlap},
+		},
+		{
+			name: "init containers reused",
+			data: checkpointV2(t, "static", "0,3-16,19-31", map[string]map[string]string{
+				"pod-a": {"init": "1,17", "cnt": "1-2,17-18"},
+			}),
+			opts:        Options{ReservedCPUs: cpuset.New(0)},
+			expectedFmt: FormatV2,
+		},
+		{
+			name: "half cores",
+			data: checkpointV2(t, "static", "0,2-16,18-31", map[string]map[string]string{
+				"pod-a": {"cnt": "1,17"},
+				"pod-b": {"cnt": "1"},
+			}),
+			opts:        Options{ReservedCPUs: cpuset.New(0), FullPCPUsOnly: true},
+			expectedFmt: FormatV2,
+			expected:    []Problem{ProblemOverlap, ProblemHalfCores},
+		},
+		{
+			name: "malformed",
+			data: checkpointV2(t, "static", "0,3-16,19-31", map[string]map[string]string{
+				"pod-a": {"cnt": "1-2,17-"},
+			}),
+			opts:          Options{ReservedCPUs: cpuset.New(0)},
+			expectedFmt:   FormatV2,
+			expected:      []Problem{ProblemMalformed, ProblemDefaultSet},
+			expectedFatal: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rp, err := Audit(tc.data, ryzen, tc.opts)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if rp.Checkpoint.Format != tc.expectedFmt {
+				t.Errorf("got format %q expected %q", rp.Checkpoint.Format, tc.expectedFmt)
+			}
+			var got []Problem
+			for _, is := range rp.Issues {
+				got = append(got, is.Problem)
+			}
+			if !reflect.DeepEqual(got, tc.expected) {
+				t.Errorf("got problems %v expected %v: %+v", got, tc.expected, rp.Issues)
+			}
+			if rp.Fatal() != tc.expectedFatal {
+				t.Errorf("got fatal %v expected %v", rp.Fatal(), tc.expectedFatal)
+			}
+		})
+	}
+
+	if _, err := Audit([]byte(`{"policyName":`), ryzen, Options{}); err == nil {
+		t.Errorf("audited a truncated checkpoint")
+	}
+}
+
+func checkpointV2(t *testing.T, policyName, defaultCPUSet string, entries map[string]map[string]string) []byte {
+	t.Helper()
+	cp := state.NewCPUManagerCheckpoint()
+	cp.PolicyName = policyName
+	cp.DefaultCPUSet = defaultCPUSet
+	for podUID, cnts := range entries {
+		cp.Entries[podUID] = cnts
+	}
+	data, err := cp.MarshalCheckpoint()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return data
+}
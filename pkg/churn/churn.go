@@ -0,0 +1,327 @@
+/*
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2026 Red Hat, Inc.
+ */
+
+// Package churn simulates a node over time: pods arrive following a Poisson
+// process, live for a random time, then leave. Running the real allocator
+// through thousands of events shows how a node holds up once fragmented,
+// which a fresh node never shows.
+package churn
+
+import (
+	"container/heap"
+	"fmt"
+	"math/rand"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+	"k8s.io/kubernetes/pkg/kubelet/cm/cpumanager/topology"
+	"k8s.io/utils/cpuset"
+
+	"github.com/ffromani/cpumgrx/pkg/alignment"
+	"github.com/ffromani/cpumgrx/pkg/cpumgrx"
+)
+
+const (
+	DefaultArrivals     = 1000
+	DefaultArrivalRate  = 1.0
+	DefaultMeanLifetime = 10.0
+	DefaultSampleEvery  = 50
+)
+
+const (
+	LifetimeExponential = "exponential"
+	LifetimeFixed       = "fixed"
+	LifetimeUniform     = "uniform"
+)
+
+type Params struct {
+	Manager cpumgrx.Params
+	// Arrivals is the amount of pods arriving during the simulation.
+	Arrivals int
+	// ArrivalRate is the mean amount of pods arriving per time unit.
+	ArrivalRate float64
+	// MeanLifetime is the mean time a pod runs before leaving, in time units.
+	MeanLifetime float64
+	// LifetimeDistribution is one of LifetimeExponential, LifetimeFixed, LifetimeUniform.
+	LifetimeDistribution string
+	// SampleEvery is the amount of events (arrivals and departures) between samples.
+	SampleEvery int
+	Seed        int64
+}
+
+// Sample is a snapshot of the node state at a given time.
+type Sample struct {
+	Time     float64 `json:"time"`
+	Events   int     `json:"events"`
+	Arrivals int     `json:"arrivals"`
+	Running  int     `json:"running"`
+	// RejectionRate is the fraction of pods rejected since the previous sample.
+	RejectionRate float64 `json:"rejectionRate"`
+	FreeCPUs      int     `json:"freeCPUs"`
+	// Fragmentation is the fraction of free CPUs which sit on partially
+	// allocated NUMA nodes, so can't be part of a whole NUMA node allocation.
+	Fragmentation float64 `json:"fragmentation"`
+	// SplitCores is the amount of physical cores having both free and allocated CPUs.
+	SplitCores int `json:"splitCores"`
+	// Misaligned is the fraction of running pods with exclusive CPUs which are, counting
+	// all their containers, worse aligned than the best possible allocation of the same size.
+	Misaligned float64 `json:"misaligned"`
+}
+
+type Report struct {
+	Samples  []Sample `json:"samples"`
+	Arrivals int      `json:"arrivals"`
+	Rejected int      `json:"rejected"`
+	Duration float64  `json:"duration"`
+}
+
+func (rp Report) RejectionRate() float64 {
+	return ratio(rp.Rejected, rp.Arrivals)
+}
+
+func Run(params Params, shapes []*v1.Pod) (Report, error) {
+	if len(shapes) == 0 {
+		return Report{}, fmt.Errorf("no pod shapes given")
+	}
+	params = withDefaults(params)
+	lifetime, err := lifetimeFunc(params.LifetimeDistribution, params.MeanLifetime)
+	if err != nil {
+		return Report{}, err
+	}
+
+	topo, err := topology.Discover(params.Manager.MachineInfo)
+	if err != nil {
+		return Report{}, err
+	}
+
+	mgrParams := params.Manager
+	mgrParams.StateFileDirectory = "" // start from a clean state
+	mgrx, err := cpumgrx.NewFromParams(mgrParams)
+	if err != nil {
+		return Report{}, err
+	}
+	defer mgrx.Close()
+
+	sim := simulator{
+		params:    params,
+		topo:      topo,
+		mgrx:      mgrx,
+		rnd:       rand.New(rand.NewSource(params.Seed)),
+		lifetime:  lifetime,
+		shapes:    shapes,
+		running:   make(map[types.UID]*v1.Pod),
+		allocated: make(map[types.UID]cpuset.CPUSet),
+	}
+	return sim.run()
+}
+
+func withDefaults(params Params) Params {
+	if params.Arrivals <= 0 {
+		params.Arrivals = DefaultArrivals
+	}
+	if params.ArrivalRate <= 0 {
+		params.ArrivalRate = DefaultArrivalRate
+	}
+	if params.MeanLifetime <= 0 {
+		params.MeanLifetime = DefaultMeanLifetime
+	}
+	if params.LifetimeDistribution == "" {
+		params.LifetimeDistribution = LifetimeExponential
+	}
+	if params.SampleEvery <= 0 {
+		params.SampleEvery = DefaultSampleEvery
+	}
+	return params
+}
+
+func lifetimeFunc(distribution string, mean float64) (func(rnd *rand.Rand) float64, error) {
+	switch distribution {
+	case LifetimeExponential:
+		return func(rnd *rand.Rand) float64 { return rnd.ExpFloat64() * mean }, nil
+	case LifetimeFixed:
+		return func(rnd *rand.Rand) float64 { return mean }, nil
+	case LifetimeUniform:
+		return func(rnd *rand.Rand) float64 { return rnd.Float64() * 2 * mean }, nil
+	}
+	return nil, fmt.Errorf("unknown lifetime distribution %q", distribution)
+}
+
+type simulator struct {
+	params   Params
+	topo     *topology.CPUTopology
+	mgrx     *cpumgrx.CpuMgrx
+	rnd      *rand.Rand
+	lifetime func(rnd *rand.Rand) float64
+	shapes   []*v1.Pod
+
+	now        float64
+	departures departureQueue
+	running    map[types.UID]*v1.Pod
+	allocated  map[types.UID]cpuset.CPUSet
+
+	events   int
+	arrivals int
+	rejected int
+	// window counters, reset at each sample
+	winArrivals int
+	winRejected int
+
+	rp Report
+}
+
+func (sim *simulator) run() (Report, error) {
+	nextArrival := sim.rnd.ExpFloat64() / sim.params.ArrivalRate
+	for sim.arrivals < sim.params.Arrivals {
+		if sim.departures.Len() > 0 && sim.departures[0].at <= nextArrival {
+			dep := heap.Pop(&sim.departures).(departure)
+			sim.now = dep.at
+			if err := sim.depart(dep.pod); err != nil {
+				return sim.rp, err
+			}
+		} else {
+			sim.now = nextArrival
+			sim.arrive()
+			nextArrival += sim.rnd.ExpFloat64() / sim.params.ArrivalRate
+		}
+		sim.events++
+		if sim.events%sim.params.SampleEvery == 0 {
+			sim.sample()
+		}
+	}
+	if sim.events%sim.params.SampleEvery != 0 {
+		sim.sample()
+	}
+
+	sim.rp.Arrivals = sim.arrivals
+	sim.rp.Rejected = sim.rejected
+	sim.rp.Duration = sim.now
+	return sim.rp, nil
+}
+
+func (sim *simulator) arrive() {
+	shape := sim.shapes[sim.rnd.Intn(len(sim.shapes))]
+	pod := shape.DeepCopy()
+	pod.Name = fmt.Sprintf("%s-%d", shape.Name, sim.arrivals)
+	pod.UID = types.UID(fmt.Sprintf("churn-%d", sim.arrivals))
+	sim.arrivals++
+	sim.winArrivals++
+
+	if _, err := sim.mgrx.Run(pod); err != nil {
+		klog.V(2).Infof("t=%.3f pod %q rejected: %v", sim.now, pod.Name, err)
+		sim.rejected++
+		sim.winRejected++
+		return
+	}
+	klog.V(4).Infof("t=%.3f pod %q admitted: %s", sim.now, pod.Name, sim.mgrx.GetCPUs(pod).String())
+	sim.running[pod.UID] = pod
+	if cpus := sim.mgrx.GetPodExclusiveCPUs(pod); !cpus.IsEmpty() {
+		sim.allocated[pod.UID] = cpus
+	}
+	heap.Push(&sim.departures, departure{
+		at:  sim.now + sim.lifetime(sim.rnd),
+		pod: pod,
+	})
+}
+
+func (sim *simulator) depart(pod *v1.Pod) error {
+	klog.V(4).Infof("t=%.3f pod %q leaving", sim.now, pod.Name)
+	delete(sim.running, pod.UID)
+	delete(sim.allocated, pod.UID)
+	return sim.mgrx.Remove(pod)
+}
+
+func (sim *simulator) sample() {
+	free := sim.mgrx.GetFreeCPUs()
+	smp := Sample{
+		Time:          sim.now,
+		Events:        sim.events,
+		Arrivals:      sim.arrivals,
+		Running:       len(sim.running),
+		RejectionRate: ratio(sim.winRejected, sim.winArrivals),
+		FreeCPUs:      free.Size(),
+		Fragmentation: sim.fragmentation(free),
+		SplitCores:    sim.splitCores(free),
+	}
+	misaligned := 0
+	for _, cpus := range sim.allocated {
+		if !alignment.IsAligned(sim.topo, cpus) {
+			misaligned++
+		}
+	}
+	smp.Misaligned = ratio(misaligned, len(sim.allocated))
+
+	sim.rp.Samples = append(sim.rp.Samples, smp)
+	sim.winArrivals = 0
+	sim.winRejected = 0
+}
+
+func (sim *simulator) fragmentation(free cpuset.CPUSet) float64 {
+	if free.IsEmpty() {
+		return 0
+	}
+	stranded := 0
+	for _, numaID := range sim.topo.CPUDetails.NUMANodes().List() {
+		cpus := sim.topo.CPUDetails.CPUsInNUMANodes(numaID).Difference(sim.params.Manager.ReservedCPUSet)
+		if cpus.IsSubsetOf(free) {
+			continue
+		}
+		stranded += cpus.Intersection(free).Size()
+	}
+	return ratio(stranded, free.Size())
+}
+
+func (sim *simulator) splitCores(free cpuset.CPUSet) int {
+	count := 0
+	for _, coreID := range sim.topo.CPUDetails.KeepOnly(free).Cores().UnsortedList() {
+		cpus := sim.topo.CPUDetails.CPUsInCores(coreID).Difference(sim.params.Manager.ReservedCPUSet)
+		if !cpus.IsSubsetOf(free) {
+			count++
+		}
+	}
+	return count
+}
+
+func ratio(num, den int) float64 {
+	if den == 0 {
+		return 0
+	}
+	return float64(num) / float64(den)
+}
+
+type departure struct {
+	at  float64
+	pod *v1.Pod
+}
+
+// departureQueue is a min-heap of departures, the earliest first.
+type departureQueue []departure
+
+func (dq departureQueue) Len() int           { return len(dq) }
+func (dq departureQueue) Less(i, j int) bool { return dq[i].at < dq[j].at }
+func (dq departureQueue) Swap(i, j int)      { dq[i], dq[j] = dq[j], dq[i] }
+
+func (dq *departureQueue) Push(x any) {
+	*dq = append(*dq, x.(departure))
+}
+
+func (dq *departureQueue) Pop() any {
+	old := *dq
+	n := len(old)
+	x := old[n-1]
+	*dq = old[:n-1]
+	return x
+}
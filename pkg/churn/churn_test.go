@@ -0,0 +1,215 @@
+/*
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2026 Red Hat, Inc.
+ */
+
+package churn
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/cpuset"
+
+	"github.com/ffromani/cpumgrx/internal/testutil"
+	"github.com/ffromani/cpumgrx/pkg/cpumgrx"
+)
+
+func TestFragmentation(t *testing.T) {
+	// NUMA node 0 has the even CPUs, NUMA node 1 the odd ones
+	sim := simulator{
+		params: Params{Manager: cpumgrx.Params{ReservedCPUSet: cpuset.New(0, 52)}},
+		topo:   testutil.Discover(t, "../../examples/machineinfo-v49-dualxeongold6230r.json"),
+	}
+	all := sim.topo.CPUDetails.CPUs().Difference(cpuset.New(0, 52))
+	numa1 := sim.topo.CPUDetails.CPUsInNUMANodes(1)
+
+	testCases := []struct {
+		name     string
+		free     cpuset.CPUSet
+		expected float64
+	}{
+		{
+			name:     "empty node",
+			free:     all,
+			expected: 0,
+		},
+		{
+			name:     "full node",
+			free:     cpuset.New(),
+			expected: 0,
+		},
+		{
+			name:     "one whole NUMA node free",
+			free:     numa1,
+			expected: 0,
+		},
+		{
+			// the 49 free CPUs of NUMA node 0 are stranded
+			name:     "one CPU allocated",
+			free:     all.Difference(cpuset.New(2)),
+			expected: 49.0 / 101.0,
+		},
+		{
+			name:     "both NUMA nodes partially allocated",
+			free:     cpuset.New(2, 3),
+			expected: 1,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := sim.fragmentation(tc.free); got != tc.expected {
+				t.Errorf("got %v expected %v", got, tc.expected)
+			}
+		})
+	}
+}
+
+func TestSplitCores(t *testing.T) {
+	// the thread siblings are N and N+16
+	sim := simulator{
+		params: Params{Manager: cpumgrx.Params{ReservedCPUSet: cpuset.New(0)}},
+		topo:   testutil.Discover(t, "../../examples/machineinfo-v49-ryzen5950x.json"),
+	}
+	all := sim.topo.CPUDetails.CPUs().Difference(cpuset.New(0))
+
+	testCases := []struct {
+		name     string
+		free     cpuset.CPUSet
+		expected int
+	}{
+		{
+			// the sibling of a reserved CPU doesn't split its core
+			name:     "empty node",
+			free:     all,
+			expected: 0,
+		},
+		{
+			name:     "full cores allocated",
+			free:     all.Difference(cpuset.New(1, 17)),
+			expected: 0,
+		},
+		{
+			name:     "threads allocated",
+			free:     all.Difference(cpuset.New(1, 2, 3, 19)),
+			expected: 2,
+		},
+		{
+			name:     "full node",
+			free:     cpuset.New(),
+			expected: 0,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := sim.splitCores(tc.free); got != tc.expected {
+				t.Errorf("got %d expected %d", got, tc.expected)
+			}
+		})
+	}
+}
+
+func TestMisaligned(t *testing.T) {
+	params := Params{
+		Manager: cpumgrx.Params{
+			PolicyName:     "static",
+			TMPolicyName:   "none",
+			MachineInfo:    testutil.ReadMachineInfo(t, "../../examples/machineinfo-v49-dualxeongold6230r.json"),
+			ReservedCPUQty: resource.MustParse("2"),
+			ReservedCPUSet: cpuset.New(0, 52),
+		},
+	}
+	mgrx, err := cpumgrx.NewFromParams(params.Manager)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	sim := simulator{
+		params:    params,
+		topo:      testutil.Discover(t, "../../examples/machineinfo-v49-dualxeongold6230r.json"),
+		mgrx:      mgrx,
+		rnd:       rand.New(rand.NewSource(42)),
+		lifetime:  func(rnd *rand.Rand) float64 { return 1 },
+		running:   make(map[types.UID]*v1.Pod),
+		allocated: make(map[types.UID]cpuset.CPUSet),
+	}
+	// the first container of b is shared, the second one can't fit the NUMA node a left
+	for _, shape := range []*v1.Pod{testutil.MakePod("a", "30"), testutil.MakePod("b", "500m", "40")} {
+		sim.shapes = []*v1.Pod{shape}
+		sim.arrive()
+	}
+	sim.sample()
+	if got := sim.rp.Samples[0]; got.Running != 2 || got.Misaligned != 0.5 {
+		t.Errorf("unexpected sample: %+v", got)
+	}
+}
+
+func TestRun(t *testing.T) {
+	machineInfo := testutil.ReadMachineInfo(t, "../../examples/machineinfo-v49-ryzen5950x.json")
+	params := Params{
+		Manager: cpumgrx.Params{
+			PolicyName:     "static",
+			MachineInfo:    machineInfo,
+			ReservedCPUQty: resource.MustParse("1"),
+			ReservedCPUSet: cpuset.New(0),
+		},
+		Arrivals:     200,
+		ArrivalRate:  2,
+		MeanLifetime: 10,
+		SampleEvery:  50,
+		Seed:         42,
+	}
+	shapes := []*v1.Pod{testutil.MakePod("small", "2"), testutil.MakePod("big", "8")}
+
+	rp, err := Run(params, shapes)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if rp.Arrivals != params.Arrivals {
+		t.Errorf("got %d arrivals expected %d", rp.Arrivals, params.Arrivals)
+	}
+	// 20 pods running on average, 5 CPUs each: the node must be overcommitted
+	if rp.Rejected == 0 || rp.Rejected >= rp.Arrivals {
+		t.Errorf("unexpected rejections: %d out of %d", rp.Rejected, rp.Arrivals)
+	}
+	if len(rp.Samples) == 0 {
+		t.Fatalf("no samples")
+	}
+	for _, smp := range rp.Samples {
+		if smp.Fragmentation < 0 || smp.Fragmentation > 1 || smp.FreeCPUs < 0 || smp.FreeCPUs > 31 {
+			t.Errorf("unexpected sample: %+v", smp)
+		}
+	}
+
+	again, err := Run(params, shapes)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(rp, again) {
+		t.Errorf("same seed, different reports:\n%+v\n%+v", rp, again)
+	}
+
+	params.LifetimeDistribution = "gaussian"
+	if _, err := Run(params, shapes); err == nil {
+		t.Errorf("unknown lifetime distribution accepted")
+	}
+	if _, err := Run(Params{Manager: params.Manager}, nil); err == nil {
+		t.Errorf("ran without pod shapes")
+	}
+}
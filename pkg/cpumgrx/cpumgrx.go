@@ -18,6 +18,8 @@ package cpumgrx
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"time"
 
 	cadvisorapi "github.com/google/cadvisor/info/v1"
@@ -25,11 +27,20 @@ import (
 
 	"k8s.io/apimachinery/pkg/api/resource"
 	"k8s.io/apimachinery/pkg/types"
+	utilfeature "k8s.io/apiserver/pkg/util/feature"
 	runtimeapi "k8s.io/cri-api/pkg/apis/runtime/v1"
+	"k8s.io/klog/v2"
+	v1qos "k8s.io/kubernetes/pkg/apis/core/v1/helper/qos"
+	"k8s.io/kubernetes/pkg/kubelet/cm/admission"
 	"k8s.io/kubernetes/pkg/kubelet/cm/containermap"
 	"k8s.io/kubernetes/pkg/kubelet/cm/cpumanager"
+	"k8s.io/kubernetes/pkg/kubelet/cm/cpumanager/state"
+	"k8s.io/kubernetes/pkg/kubelet/cm/cpumanager/topology"
 	"k8s.io/kubernetes/pkg/kubelet/cm/topologymanager"
+	"k8s.io/kubernetes/pkg/kubelet/lifecycle"
 	"k8s.io/utils/cpuset"
+
+	"github.com/ffromani/cpumgrx/pkg/klogcapture"
 )
 
 const (
@@ -37,13 +48,40 @@ const (
 	reconcilePeriod = 10 * time.Minute
 )
 
+const (
+	// CauseSMTAlignment means the pod asked for CPUs which cannot be full physical cores
+	CauseSMTAlignment = "SMTAlignment"
+	// CauseTopologyAffinity means the topology manager policy could not be satisfied
+	CauseTopologyAffinity = "TopologyAffinity"
+	// CauseInsufficientCPUs means there were not enough free CPUs left
+	CauseInsufficientCPUs = "InsufficientCPUs"
+	// CauseInvalidRequest means the pod was malformed
+	CauseInvalidRequest = "InvalidRequest"
+	// CauseUnexpected covers all the other failures
+	CauseUnexpected = "Unexpected"
+)
+
 type Params struct {
-	PolicyName         string
-	TMPolicyName       string
-	Hint               topologymanager.TopologyHint
-	MachineInfo        *cadvisorapi.MachineInfo
-	ReservedCPUQty     resource.Quantity
-	ReservedCPUSet     cpuset.CPUSet
+	PolicyName   string
+	TMPolicyName string
+	TMScopeName  string
+	// TMPolicyOptions are the topology manager policy options, like kubelet's topologyManagerPolicyOptions
+	TMPolicyOptions map[string]string
+	// CPUPolicyOptions are the cpu manager policy options, like kubelet's cpuManagerPolicyOptions
+	CPUPolicyOptions map[string]string
+	// Hint, if set, is forced as topology manager affinity for all the containers,
+	// bypassing the topology manager admission. Otherwise, a real topology manager
+	// is used if TMPolicyName is set.
+	Hint topologymanager.TopologyHint
+	// FeatureGates are set on the process-wide feature gate, like kubelet's --feature-gates.
+	// They outlive the manager: the gates not listed keep the value the previous managers set.
+	FeatureGates   map[string]bool
+	MachineInfo    *cadvisorapi.MachineInfo
+	ReservedCPUQty resource.Quantity
+	ReservedCPUSet cpuset.CPUSet
+	// StateFileDirectory, if set, is where the cpu manager keeps its cpu_manager_state
+	// checkpoint, loading the one found there, like the kubelet does. Otherwise the state
+	// lives as long as the manager does, and is never shared with other managers.
 	StateFileDirectory string
 }
 
@@ -101,15 +139,62 @@ func (psp fakePodStatusProvider) GetPodStatus(uid types.UID) (v1.PodStatus, bool
 	return v1.PodStatus{}, false
 }
 
+// AdmitResult is the outcome of the admission of a pod, like the kubelet reports it.
+type AdmitResult struct {
+	Admit bool `json:"admit"`
+	// Reason and Message are what the pod status would show if rejected.
+	Reason  string `json:"reason,omitempty"`
+	Message string `json:"message,omitempty"`
+	// Cause is one of the Cause* constants, set if rejected.
+	Cause string `json:"cause,omitempty"`
+}
+
+func (ar AdmitResult) String() string {
+	if ar.Admit {
+		return "admitted"
+	}
+	return "rejected: " + ar.Reason + ": " + ar.Message
+}
+
+// makeAdmitResult describes the admission result. allocErr is the error of the failed
+// allocation, if any, which tells more than the result does.
+func makeAdmitResult(res lifecycle.PodAdmitResult, allocErr error) AdmitResult {
+	ar := AdmitResult{
+		Admit:   res.Admit,
+		Reason:  res.Reason,
+		Message: res.Message,
+	}
+	if ar.Admit {
+		return ar
+	}
+	switch {
+	case ar.Reason == cpumanager.ErrorSMTAlignment:
+		ar.Cause = CauseSMTAlignment
+	case ar.Reason == topologymanager.ErrorTopologyAffinity:
+		ar.Cause = CauseTopologyAffinity
+	case errors.As(allocErr, new(*insufficientCPUsError)):
+		ar.Cause = CauseInsufficientCPUs
+	default:
+		ar.Cause = CauseUnexpected
+	}
+	return ar
+}
+
 type CpuMgrx struct {
-	cpuMgr     cpumanager.Manager
+	cpuMgr     cpuManager
+	allocs     *allocationTracker
+	topoMgr    topologymanager.Manager
+	topo       *topology.CPUTopology
+	reserved   cpuset.CPUSet
 	fakeTm     fakeTMStore
 	fakeRs     fakeRuntimeService
 	policyName string
 
 	sourcesReady      *fakeSourcesReady
 	podStatusProvider fakePodStatusProvider
-	initialContainers containermap.ContainerMap
+	// containers maps the IDs of the containers the managers know about. The managers
+	// keep their own copy, which the upstream one does not expose.
+	containers containermap.ContainerMap
 }
 
 func (cmx *CpuMgrx) GetPolicyName() string {
@@ -117,19 +202,163 @@ func (cmx *CpuMgrx) GetPolicyName() string {
 }
 
 func (cmx *CpuMgrx) String() string {
-	return "N/A"
+	tmPolicyName := cmx.fakeTm.PolicyName
+	if tmPolicyName == "" {
+		tmPolicyName = "none"
+	}
+	free := cmx.GetFreeCPUs()
+	return fmt.Sprintf("cpu manager policy %q topology manager policy %q free CPUs %q (%d)", cmx.policyName, tmPolicyName, free.String(), free.Size())
 }
 
-func (cmx *CpuMgrx) Run(pod *v1.Pod) (cpuset.CPUSet, error) {
+// Run admits the pod and describes what all its containers got. If the pod is
+// rejected, the error is one of the typed errors of this package, which carries
+// the same AdmitResult the result does. If a klog capture is active, the result
+// also holds what the managers logged meanwhile.
+func (cmx *CpuMgrx) Run(pod *v1.Pod) (res Result, err error) {
+	if capture := klogcapture.Active(); capture != nil {
+		capture.Begin()
+		defer func() {
+			res.Log = capture.End()
+		}()
+	}
+	if err := validatePod(pod); err != nil {
+		ar := AdmitResult{
+			Reason:  "InvalidRequest",
+			Message: err.Error(),
+			Cause:   CauseInvalidRequest,
+		}
+		return Result{AdmitResult: ar, QOSClass: v1qos.GetPodQOS(pod)}, rejectionError(ar)
+	}
+	res = Result{
+		AdmitResult: cmx.Admit(pod),
+		QOSClass:    v1qos.GetPodQOS(pod),
+	}
+	if !res.Admit {
+		return res, rejectionError(res.AdmitResult)
+	}
+	res.Containers = cmx.DescribeContainers(pod)
+	return res, nil
+}
+
+// GetCPUs returns the CPUs the first app container can run on, exclusive or shared.
+func (cmx *CpuMgrx) GetCPUs(pod *v1.Pod) cpuset.CPUSet {
 	cnt := &pod.Spec.Containers[0]
-	err := cmx.cpuMgr.Allocate(pod, cnt)
-	if err != nil {
-		return cpuset.CPUSet{}, err
+	return cmx.cpuMgr.State().GetCPUSetOrDefault(string(pod.UID), cnt.Name)
+}
+
+// Admit runs the pod admission like the kubelet does. If the pod is rejected,
+// the resources allocated to the containers processed so far are released.
+func (cmx *CpuMgrx) Admit(pod *v1.Pod) AdmitResult {
+	res := cmx.admit(pod)
+	if !res.Admit {
+		cmx.rollback(pod)
+		return res
+	}
+
+	// the kubelet does this once the containers are created
+	for _, cnt := range allContainers(pod) {
+		cntID := makeContainerID(pod, &cnt)
+		cmx.addContainer(pod, &cnt, cntID)
+	}
+	return res
+}
+
+func (cmx *CpuMgrx) admit(pod *v1.Pod) AdmitResult {
+	cmx.allocs.lastErr = nil
+	var res lifecycle.PodAdmitResult
+	if cmx.topoMgr != nil {
+		res = cmx.topoMgr.Admit(&lifecycle.PodAdmitAttributes{Pod: pod})
+	} else {
+		res = cmx.allocate(pod)
+	}
+	return makeAdmitResult(res, cmx.allocs.lastErr)
+}
+
+// allocate mimics the topology manager "none" scope, with the forced hint already in place.
+func (cmx *CpuMgrx) allocate(pod *v1.Pod) lifecycle.PodAdmitResult {
+	for _, cnt := range allContainers(pod) {
+		if err := cmx.cpuMgr.Allocate(pod, &cnt); err != nil {
+			return admission.GetPodAdmitResult(err)
+		}
+	}
+	return admission.GetPodAdmitResult(nil)
+}
+
+// rollback releases the CPUs the rejected pod got before the failure. The kubelet
+// does the same once it finds out the pod is not active, cleaning up the stale state.
+func (cmx *CpuMgrx) rollback(pod *v1.Pod) {
+	for _, cnt := range allContainers(pod) {
+		if _, ok := cmx.cpuMgr.State().GetCPUSet(string(pod.UID), cnt.Name); !ok {
+			continue
+		}
+		// the manager releases only the containers it knows about
+		cntID := makeContainerID(pod, &cnt)
+		cmx.cpuMgr.AddContainer(pod, &cnt, cntID)
+		if err := cmx.cpuMgr.RemoveContainer(cntID); err != nil {
+			klog.Warningf("rollback of pod %q container %q failed: %v", pod.Name, cnt.Name, err)
+		}
+	}
+}
+
+// Remove releases the CPUs allocated to the pod, like the kubelet does once the pod is deleted.
+func (cmx *CpuMgrx) Remove(pod *v1.Pod) error {
+	for _, cnt := range allContainers(pod) {
+		if err := cmx.removeContainer(makeContainerID(pod, &cnt)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (cmx *CpuMgrx) addContainer(pod *v1.Pod, cnt *v1.Container, cntID string) {
+	cmx.cpuMgr.AddContainer(pod, cnt, cntID)
+	if cmx.topoMgr != nil {
+		cmx.topoMgr.AddContainer(pod, cnt, cntID)
+	}
+	cmx.containers.Add(string(pod.UID), cnt.Name, cntID)
+}
+
+func (cmx *CpuMgrx) removeContainer(cntID string) error {
+	if err := cmx.cpuMgr.RemoveContainer(cntID); err != nil {
+		return err
+	}
+	cmx.containers.RemoveByContainerID(cntID)
+	if cmx.topoMgr != nil {
+		return cmx.topoMgr.RemoveContainer(cntID)
+	}
+	return nil
+}
+
+// GetFreeCPUs returns the CPUs which can still be allocated exclusively.
+func (cmx *CpuMgrx) GetFreeCPUs() cpuset.CPUSet {
+	return cmx.cpuMgr.State().GetDefaultCPUSet().Intersection(cmx.cpuMgr.GetAllocatableCPUs())
+}
+
+// GetReservedCPUs returns the CPUs reserved for the system, never allocated exclusively.
+func (cmx *CpuMgrx) GetReservedCPUs() cpuset.CPUSet {
+	return cmx.reserved
+}
+
+// GetSharedCPUs returns the shared pool, which the containers without exclusive CPUs run on.
+func (cmx *CpuMgrx) GetSharedCPUs() cpuset.CPUSet {
+	return cmx.cpuMgr.State().GetDefaultCPUSet()
+}
+
+func (cmx *CpuMgrx) GetExclusiveCPUs(pod *v1.Pod) cpuset.CPUSet {
+	return cmx.GetContainerExclusiveCPUs(pod, pod.Spec.Containers[0].Name)
+}
+
+// GetPodExclusiveCPUs returns the exclusive CPUs of all the containers of the pod.
+func (cmx *CpuMgrx) GetPodExclusiveCPUs(pod *v1.Pod) cpuset.CPUSet {
+	res := cpuset.New()
+	for _, cnt := range allContainers(pod) {
+		res = res.Union(cmx.cpuMgr.GetExclusiveCPUs(string(pod.UID), cnt.Name))
 	}
+	return res
+}
 
-	state := cmx.cpuMgr.State()
-	cpus := state.GetCPUSetOrDefault(string(pod.UID), cnt.Name)
-	return cpus, nil
+func (cmx *CpuMgrx) GetContainerExclusiveCPUs(pod *v1.Pod, containerName string) cpuset.CPUSet {
+	return cmx.cpuMgr.GetExclusiveCPUs(string(pod.UID), containerName)
 }
 
 func (cmx *CpuMgrx) GetTopologyHints(pod *v1.Pod) map[string][]topologymanager.TopologyHint {
@@ -137,7 +366,47 @@ func (cmx *CpuMgrx) GetTopologyHints(pod *v1.Pod) map[string][]topologymanager.T
 	return cmx.cpuMgr.GetTopologyHints(pod, cnt)
 }
 
+// GuaranteedCPUs returns the amount of exclusive CPUs the static policy
+// would allocate to the container of the pod, mirroring what the policy itself does.
+func GuaranteedCPUs(pod *v1.Pod, cnt *v1.Container) int {
+	return guaranteedCPUs(pod, cnt)
+}
+
+func guaranteedCPUs(pod *v1.Pod, cnt *v1.Container) int {
+	if v1qos.GetPodQOS(pod) != v1.PodQOSGuaranteed {
+		return 0
+	}
+	cpuQuantity := cnt.Resources.Requests[v1.ResourceCPU]
+	if cpuQuantity.Value()*1000 != cpuQuantity.MilliValue() {
+		return 0
+	}
+	return int(cpuQuantity.Value())
+}
+
+func allContainers(pod *v1.Pod) []v1.Container {
+	return append(append([]v1.Container{}, pod.Spec.InitContainers...), pod.Spec.Containers...)
+}
+
+func makeContainerID(pod *v1.Pod, cnt *v1.Container) string {
+	// any unique value will do, we never talk to a real runtime
+	return string(pod.UID) + "/" + cnt.Name
+}
+
+// Close releases the resources of the manager, which must not be used afterwards.
+func (cmx *CpuMgrx) Close() error {
+	// the state lives in memory or in the state directory the caller owns
+	return nil
+}
+
 func NewFromParams(params Params) (*CpuMgrx, error) {
+	return newFromParams(params, containermap.ContainerMap{}, nil)
+}
+
+// newFromParams creates the manager, which starts knowing about the initial containers,
+// like the kubelet does with the containers found running. With a state directory, the
+// upstream manager keeps the state in the checkpoint file there, like the kubelet does.
+// Otherwise the state is kept in memory, starting from the initial state if given.
+func newFromParams(params Params, initialContainers containermap.ContainerMap, initialState state.State) (*CpuMgrx, error) {
 	nodeAllocatableReservation := v1.ResourceList{
 		v1.ResourceCPU: params.ReservedCPUQty,
 	}
@@ -146,28 +415,96 @@ func NewFromParams(params Params) (*CpuMgrx, error) {
 		PolicyName: params.TMPolicyName,
 	}
 
-	cpuPolicyOptions := make(map[string]string)
-	mgr, err := cpumanager.NewManager(params.PolicyName, cpuPolicyOptions, reconcilePeriod, params.MachineInfo, params.ReservedCPUSet, nodeAllocatableReservation, params.StateFileDirectory, fakeTm)
+	if len(params.FeatureGates) > 0 {
+		if err := utilfeature.DefaultMutableFeatureGate.SetFromMap(params.FeatureGates); err != nil {
+			return nil, err
+		}
+	}
+
+	topo, err := topology.Discover(params.MachineInfo)
 	if err != nil {
 		return nil, err
 	}
 
+	var topoMgr topologymanager.Manager
+	var tmStore topologymanager.Store = fakeTm
+	if params.Hint.NUMANodeAffinity == nil && params.TMPolicyName != "" {
+		scopeName := params.TMScopeName
+		if scopeName == "" {
+			scopeName = "container"
+		}
+		topoMgr, err = topologymanager.NewManager(params.MachineInfo.Topology, params.TMPolicyName, scopeName, params.TMPolicyOptions)
+		if err != nil {
+			return nil, err
+		}
+		tmStore = topoMgr
+	}
+
+	cpuPolicyOptions := params.CPUPolicyOptions
+	if cpuPolicyOptions == nil {
+		cpuPolicyOptions = make(map[string]string)
+	}
+
 	fakeRs := fakeRuntimeService{}
 	cpuMgrx := CpuMgrx{
-		cpuMgr:     mgr,
+		topoMgr:    topoMgr,
+		topo:       topo,
+		reserved:   params.ReservedCPUSet,
 		fakeRs:     fakeRs,
 		fakeTm:     fakeTm,
 		policyName: params.PolicyName,
 
-		// TODO: always empty
-		// TODO: allow to load state to check more complex allocations? is the state file sufficient?
-		initialContainers: containermap.ContainerMap{},
+		containers:        initialContainers,
 		sourcesReady:      new(fakeSourcesReady),
 		podStatusProvider: fakePodStatusProvider{},
 	}
 
-	if err := cpuMgrx.cpuMgr.Start(fakeActivePods, cpuMgrx.sourcesReady, cpuMgrx.podStatusProvider, fakeRs, cpuMgrx.initialContainers); err != nil {
-		return nil, err
+	if params.StateFileDirectory != "" {
+		mgr, err := cpumanager.NewManager(params.PolicyName, cpuPolicyOptions, reconcilePeriod, params.MachineInfo, params.ReservedCPUSet, nodeAllocatableReservation, params.StateFileDirectory, tmStore)
+		if err != nil {
+			return nil, err
+		}
+		if err := mgr.Start(fakeActivePods, cpuMgrx.sourcesReady, cpuMgrx.podStatusProvider, fakeRs, initialContainers.Clone()); err != nil {
+			return nil, err
+		}
+		cpuMgrx.cpuMgr = mgr
+	} else {
+		policy, err := newPolicy(params, topo, tmStore, cpuPolicyOptions)
+		if err != nil {
+			return nil, err
+		}
+		if initialState == nil {
+			initialState = state.NewMemoryState()
+		}
+		mgr, err := newMemoryManager(policy, initialState, initialContainers.Clone())
+		if err != nil {
+			return nil, err
+		}
+		cpuMgrx.cpuMgr = mgr
+	}
+	if params.PolicyName == "static" {
+		// without an explicit set, the policy picks the reserved CPUs out of their amount
+		cpuMgrx.reserved = topo.CPUDetails.CPUs().Difference(cpuMgrx.cpuMgr.GetAllocatableCPUs())
+	}
+	cpuMgrx.allocs = &allocationTracker{
+		cpuManager: cpuMgrx.cpuMgr,
+		reserved:   cpuMgrx.reserved,
+	}
+	cpuMgrx.cpuMgr = cpuMgrx.allocs
+	if topoMgr != nil {
+		topoMgr.AddHintProvider(cpuMgrx.cpuMgr)
 	}
 	return &cpuMgrx, nil
 }
+
+// PickReservedCPUs returns the CPUs the static policy reserves when given only their amount,
+// as the kubelet does without reservedSystemCPUs. The reserved set of the params is ignored.
+func PickReservedCPUs(params Params) (cpuset.CPUSet, error) {
+	params.ReservedCPUSet = cpuset.New()
+	cmx, err := NewFromParams(params)
+	if err != nil {
+		return cpuset.New(), err
+	}
+	defer cmx.Close()
+	return cmx.GetReservedCPUs(), nil
+}
@@ -0,0 +1,140 @@
+/*
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2026 Red Hat, Inc.
+ */
+
+package cpumgrx
+
+import (
+	"errors"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/kubernetes/pkg/kubelet/lifecycle"
+	"k8s.io/utils/cpuset"
+
+	"github.com/ffromani/cpumgrx/internal/testutil"
+)
+
+func TestMakeAdmitResult(t *testing.T) {
+	testCases := []struct {
+		name     string
+		res      lifecycle.PodAdmitResult
+		allocErr error
+		expected string
+	}{
+		{
+			name: "admitted",
+			res:  lifecycle.PodAdmitResult{Admit: true},
+		},
+		{
+			name:     "smt alignment",
+			res:      lifecycle.PodAdmitResult{Reason: "SMTAlignmentError", Message: "SMT Alignment Error: requested 3 cpus not multiple cpus per core = 2"},
+			expected: CauseSMTAlignment,
+		},
+		{
+			name:     "topology affinity",
+			res:      lifecycle.PodAdmitResult{Reason: "TopologyAffinityError", Message: "Resources cannot be allocated with Topology locality"},
+			expected: CauseTopologyAffinity,
+		},
+		{
+			name:     "insufficient cpus",
+			res:      lifecycle.PodAdmitResult{Reason: "UnexpectedAdmissionError", Message: "Allocate failed due to not enough cpus available to satisfy request: requested=40, available=22, which is unexpected"},
+			allocErr: &insufficientCPUsError{err: errors.New("not enough cpus available to satisfy request: requested=40, available=22")},
+			expected: CauseInsufficientCPUs,
+		},
+		{
+			name:     "insufficient cpus message only",
+			res:      lifecycle.PodAdmitResult{Reason: "UnexpectedAdmissionError", Message: "Allocate failed due to not enough cpus available to satisfy request: requested=40, available=22, which is unexpected"},
+			allocErr: errors.New("not enough cpus available to satisfy request: requested=40, available=22"),
+			expected: CauseUnexpected,
+		},
+		{
+			name:     "unexpected",
+			res:      lifecycle.PodAdmitResult{Reason: "UnexpectedAdmissionError", Message: "Allocate failed due to foobar, which is unexpected"},
+			expected: CauseUnexpected,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := makeAdmitResult(tc.res, tc.allocErr)
+			if got.Cause != tc.expected {
+				t.Errorf("got cause %q expected %q", got.Cause, tc.expected)
+			}
+		})
+	}
+}
+
+func TestAdmitRollback(t *testing.T) {
+	for _, tmPolicy := range []string{"", "none"} {
+		t.Run("tm policy "+tmPolicy, func(t *testing.T) {
+			mgrx, err := NewFromParams(Params{
+				PolicyName:     "static",
+				TMPolicyName:   tmPolicy,
+				MachineInfo:    testutil.ReadMachineInfo(t, "../../examples/machineinfo-v49-ryzen5950x.json"),
+				ReservedCPUQty: resource.MustParse("1"),
+				ReservedCPUSet: cpuset.New(0),
+			})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			defer mgrx.Close()
+			shared := mgrx.GetSharedCPUs()
+
+			// the first container fits, the second one doesn't fit in what is left
+			pod := makeTestPod("4")
+			big := pod.Spec.Containers[0].DeepCopy()
+			big.Name = "big"
+			big.Resources.Limits[v1.ResourceCPU] = resource.MustParse("30")
+			big.Resources.Requests[v1.ResourceCPU] = resource.MustParse("30")
+			pod.Spec.Containers = append(pod.Spec.Containers, *big)
+
+			res := mgrx.Admit(pod)
+			if res.Admit || res.Cause != CauseInsufficientCPUs {
+				t.Fatalf("unexpected result: %+v", res)
+			}
+			if cpus := mgrx.GetContainerExclusiveCPUs(pod, "cnt"); !cpus.IsEmpty() {
+				t.Errorf("first container kept CPUs %v", cpus)
+			}
+			if got := mgrx.GetSharedCPUs(); !got.Equals(shared) {
+				t.Errorf("got shared CPUs %v expected %v", got, shared)
+			}
+		})
+	}
+}
+
+func TestPickReservedCPUs(t *testing.T) {
+	params := Params{
+		PolicyName:     "static",
+		MachineInfo:    testutil.ReadMachineInfo(t, "../../examples/machineinfo-v49-ryzen5950x.json"),
+		ReservedCPUQty: resource.MustParse("3"),
+		// ignored
+		ReservedCPUSet: cpuset.New(4, 5, 6),
+	}
+	got, err := PickReservedCPUs(params)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// the lowest numbered core first, then a thread of the next one
+	if expected := cpuset.New(0, 1, 16); !got.Equals(expected) {
+		t.Errorf("got reserved %q expected %q", got.String(), expected.String())
+	}
+
+	params.ReservedCPUSet = cpuset.New(0, 16)
+	if _, err := NewFromParams(params); err == nil {
+		t.Errorf("manager created with 3 reserved CPUs out of 2")
+	}
+}
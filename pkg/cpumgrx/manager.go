@@ -0,0 +1,213 @@
+/*
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2026 Red Hat, Inc.
+ */
+
+package cpumgrx
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+	"k8s.io/kubernetes/pkg/kubelet/cm/admission"
+	"k8s.io/kubernetes/pkg/kubelet/cm/containermap"
+	"k8s.io/kubernetes/pkg/kubelet/cm/cpumanager"
+	"k8s.io/kubernetes/pkg/kubelet/cm/cpumanager/state"
+	"k8s.io/kubernetes/pkg/kubelet/cm/cpumanager/topology"
+	"k8s.io/kubernetes/pkg/kubelet/cm/topologymanager"
+	"k8s.io/utils/cpuset"
+)
+
+// cpuManager is the part of the kubelet cpu manager we drive. Both the upstream
+// manager, which keeps the state in a checkpoint file, and memoryManager have it.
+type cpuManager interface {
+	Allocate(pod *v1.Pod, container *v1.Container) error
+	AddContainer(pod *v1.Pod, container *v1.Container, containerID string)
+	RemoveContainer(containerID string) error
+	State() state.Reader
+	GetTopologyHints(pod *v1.Pod, container *v1.Container) map[string][]topologymanager.TopologyHint
+	GetPodTopologyHints(pod *v1.Pod) map[string][]topologymanager.TopologyHint
+	GetExclusiveCPUs(podUID, containerName string) cpuset.CPUSet
+	GetAllocatableCPUs() cpuset.CPUSet
+}
+
+// memoryManager drives the cpu manager policies like the upstream manager does, but keeps
+// the state in memory: nothing is written anywhere unless a checkpoint is asked for.
+// The reconcile loop and the stale state removal are left out, like they are in
+// the upstream manager we run, which never finds its sources ready.
+type memoryManager struct {
+	sync.Mutex
+	policy          cpumanager.Policy
+	state           state.State
+	containerMap    containermap.ContainerMap
+	allocatableCPUs cpuset.CPUSet
+}
+
+var _ cpuManager = &memoryManager{}
+
+// newPolicy creates the cpu manager policy like the upstream cpumanager.NewManager does.
+func newPolicy(params Params, topo *topology.CPUTopology, affinity topologymanager.Store, cpuPolicyOptions map[string]string) (cpumanager.Policy, error) {
+	switch params.PolicyName {
+	case string(cpumanager.PolicyNone):
+		policy, err := cpumanager.NewNonePolicy(cpuPolicyOptions)
+		if err != nil {
+			return nil, fmt.Errorf("new none policy error: %w", err)
+		}
+		return policy, nil
+	case string(cpumanager.PolicyStatic):
+		if params.ReservedCPUQty.IsZero() {
+			return nil, fmt.Errorf("[cpumanager] the static policy requires systemreserved.cpu + kubereserved.cpu to be greater than zero")
+		}
+		// fractional CPUs cannot be exclusively allocated
+		numReservedCPUs := int(math.Ceil(float64(params.ReservedCPUQty.MilliValue()) / 1000))
+		policy, err := cpumanager.NewStaticPolicy(topo, numReservedCPUs, params.ReservedCPUSet, affinity, cpuPolicyOptions)
+		if err != nil {
+			return nil, fmt.Errorf("new static policy error: %w", err)
+		}
+		return policy, nil
+	default:
+		return nil, fmt.Errorf("unknown policy: \"%s\"", params.PolicyName)
+	}
+}
+
+// newMemoryManager starts the policy on the given state, which it validates like the
+// kubelet does on startup.
+func newMemoryManager(policy cpumanager.Policy, st state.State, initialContainers containermap.ContainerMap) (*memoryManager, error) {
+	if err := policy.Start(st); err != nil {
+		return nil, err
+	}
+	return &memoryManager{
+		policy:          policy,
+		state:           st,
+		containerMap:    initialContainers,
+		allocatableCPUs: policy.GetAllocatableCPUs(st),
+	}, nil
+}
+
+func (m *memoryManager) Allocate(pod *v1.Pod, container *v1.Container) error {
+	m.Lock()
+	defer m.Unlock()
+	if err := m.policy.Allocate(m.state, pod, container); err != nil {
+		klog.ErrorS(err, "Allocate error")
+		return err
+	}
+	return nil
+}
+
+func (m *memoryManager) AddContainer(pod *v1.Pod, container *v1.Container, containerID string) {
+	m.Lock()
+	defer m.Unlock()
+	m.containerMap.Add(string(pod.UID), container.Name, containerID)
+}
+
+func (m *memoryManager) RemoveContainer(containerID string) error {
+	m.Lock()
+	defer m.Unlock()
+	podUID, containerName, err := m.containerMap.GetContainerRef(containerID)
+	if err != nil {
+		return nil
+	}
+	if err := m.policy.RemoveContainer(m.state, podUID, containerName); err != nil {
+		klog.ErrorS(err, "RemoveContainer error")
+		return err
+	}
+	m.containerMap.RemoveByContainerID(containerID)
+	return nil
+}
+
+func (m *memoryManager) State() state.Reader {
+	return m.state
+}
+
+func (m *memoryManager) GetTopologyHints(pod *v1.Pod, container *v1.Container) map[string][]topologymanager.TopologyHint {
+	return m.policy.GetTopologyHints(m.state, pod, container)
+}
+
+func (m *memoryManager) GetPodTopologyHints(pod *v1.Pod) map[string][]topologymanager.TopologyHint {
+	return m.policy.GetPodTopologyHints(m.state, pod)
+}
+
+func (m *memoryManager) GetExclusiveCPUs(podUID, containerName string) cpuset.CPUSet {
+	if cpus, ok := m.state.GetCPUSet(podUID, containerName); ok {
+		return cpus
+	}
+	return cpuset.CPUSet{}
+}
+
+func (m *memoryManager) GetAllocatableCPUs() cpuset.CPUSet {
+	return m.allocatableCPUs.Clone()
+}
+
+// insufficientCPUsError marks the allocation failures due to the lack of free CPUs,
+// which the static policy reports as plain errors. The message is left as it is.
+type insufficientCPUsError struct {
+	err error
+}
+
+func (e *insufficientCPUsError) Error() string {
+	return e.err.Error()
+}
+
+func (e *insufficientCPUsError) Unwrap() error {
+	return e.err
+}
+
+// allocationTracker remembers the error of the last allocation, since the admission
+// result carries only its message. The lack of free CPUs is checked right after the
+// failure, on the same state the policy saw.
+type allocationTracker struct {
+	cpuManager
+	reserved cpuset.CPUSet
+	lastErr  error
+}
+
+func (at *allocationTracker) Allocate(pod *v1.Pod, container *v1.Container) error {
+	err := at.cpuManager.Allocate(pod, container)
+	var admissionErr admission.Error
+	if err != nil && !errors.As(err, &admissionErr) && guaranteedCPUs(pod, container) > at.availableCPUs(pod).Size() {
+		err = &insufficientCPUsError{err: err}
+	}
+	at.lastErr = err
+	return err
+}
+
+// availableCPUs returns what the static policy can give to a container of the pod: the
+// free CPUs, plus the ones of the init containers of the same pod, which are done by then.
+func (at *allocationTracker) availableCPUs(pod *v1.Pod) cpuset.CPUSet {
+	st := at.State()
+	podUID := string(pod.UID)
+	reusable := cpuset.New()
+	inUse := cpuset.New()
+	for _, cnt := range pod.Spec.InitContainers {
+		cpus, ok := st.GetCPUSet(podUID, cnt.Name)
+		if !ok {
+			continue
+		}
+		if cnt.RestartPolicy != nil && *cnt.RestartPolicy == v1.ContainerRestartPolicyAlways {
+			inUse = inUse.Union(cpus) // sidecars keep running
+		} else {
+			reusable = reusable.Union(cpus)
+		}
+	}
+	for _, cnt := range pod.Spec.Containers {
+		if cpus, ok := st.GetCPUSet(podUID, cnt.Name); ok {
+			inUse = inUse.Union(cpus)
+		}
+	}
+	return st.GetDefaultCPUSet().Difference(at.reserved).Union(reusable.Difference(inUse))
+}
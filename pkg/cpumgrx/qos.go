@@ -0,0 +1,92 @@
+/*
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2026 Red Hat, Inc.
+ */
+
+package cpumgrx
+
+import (
+	"fmt"
+
+	v1 "k8s.io/api/core/v1"
+	utilfeature "k8s.io/apiserver/pkg/util/feature"
+	v1qos "k8s.io/kubernetes/pkg/apis/core/v1/helper/qos"
+	"k8s.io/kubernetes/pkg/features"
+)
+
+// SharedReason tells why the container gets no exclusive CPUs from the manager,
+// or returns an empty string if the container is eligible for them.
+func (cmx *CpuMgrx) SharedReason(pod *v1.Pod, cnt *v1.Container) string {
+	if cmx.policyName != "static" {
+		return fmt.Sprintf("the %q cpu manager policy never allocates exclusive CPUs", cmx.policyName)
+	}
+	return SharedReason(pod, cnt)
+}
+
+// SharedReason tells why the static policy would give no exclusive CPUs to the container,
+// or returns an empty string if the container is eligible for them. The pod should be
+// as the API server stores it: requests are not defaulted from limits here.
+func SharedReason(pod *v1.Pod, cnt *v1.Container) string {
+	if qosClass := v1qos.GetPodQOS(pod); qosClass != v1.PodQOSGuaranteed {
+		return fmt.Sprintf("the pod QoS class is %s: %s", qosClass, notGuaranteedReason(pod))
+	}
+	cpuQuantity, ok := cnt.Resources.Requests[v1.ResourceCPU]
+	if !ok || cpuQuantity.IsZero() {
+		return fmt.Sprintf("container %q requests no CPUs", cnt.Name)
+	}
+	if cpuQuantity.Value()*1000 != cpuQuantity.MilliValue() {
+		return fmt.Sprintf("container %q requests %s CPUs, not an integer amount", cnt.Name, cpuQuantity.String())
+	}
+	return ""
+}
+
+// notGuaranteedReason points to the first resource spec which makes the pod not guaranteed.
+func notGuaranteedReason(pod *v1.Pod) string {
+	if utilfeature.DefaultFeatureGate.Enabled(features.PodLevelResources) && pod.Spec.Resources != nil {
+		if reason := notGuaranteedResources("pod", *pod.Spec.Resources); reason != "" {
+			return reason
+		}
+	} else {
+		for _, cnt := range allContainers(pod) {
+			if reason := notGuaranteedResources(fmt.Sprintf("container %q", cnt.Name), cnt.Resources); reason != "" {
+				return reason
+			}
+		}
+	}
+	// each container looks fine, but the sums don't match
+	return "the CPU and memory requests of the pod don't add up to its limits"
+}
+
+func notGuaranteedResources(owner string, res v1.ResourceRequirements) string {
+	if len(res.Requests) == 0 && len(res.Limits) == 0 {
+		return owner + " sets no requests nor limits"
+	}
+	names := []v1.ResourceName{v1.ResourceCPU, v1.ResourceMemory}
+	for _, name := range names {
+		if lim, ok := res.Limits[name]; !ok || lim.IsZero() {
+			return fmt.Sprintf("%s has no %s limit", owner, name)
+		}
+	}
+	for _, name := range names {
+		lim := res.Limits[name]
+		req, ok := res.Requests[name]
+		if !ok {
+			return fmt.Sprintf("%s has no %s request", owner, name)
+		}
+		if req.Cmp(lim) != 0 {
+			return fmt.Sprintf("%s %s request %s differs from the limit %s", owner, name, req.String(), lim.String())
+		}
+	}
+	return ""
+}
@@ -0,0 +1,94 @@
+/*
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2026 Red Hat, Inc.
+ */
+
+package cpumgrx
+
+import (
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+func TestSharedReason(t *testing.T) {
+	testCases := []struct {
+		name     string
+		pod      func() *v1.Pod
+		expected string
+	}{
+		{
+			name:     "eligible",
+			pod:      func() *v1.Pod { return makeTestPod("4") },
+			expected: "",
+		},
+		{
+			name:     "fractional",
+			pod:      func() *v1.Pod { return makeTestPod("1500m") },
+			expected: `container "cnt" requests 1500m CPUs, not an integer amount`,
+		},
+		{
+			name: "request differs from limit",
+			pod: func() *v1.Pod {
+				pod := makeTestPod("4")
+				pod.Spec.Containers[0].Resources.Requests[v1.ResourceCPU] = resource.MustParse("2")
+				return pod
+			},
+			expected: `the pod QoS class is Burstable: container "cnt" cpu request 2 differs from the limit 4`,
+		},
+		{
+			name: "memory missing from limits",
+			pod: func() *v1.Pod {
+				pod := makeTestPod("4")
+				delete(pod.Spec.Containers[0].Resources.Limits, v1.ResourceMemory)
+				return pod
+			},
+			expected: `the pod QoS class is Burstable: container "cnt" has no memory limit`,
+		},
+		{
+			name: "best effort",
+			pod: func() *v1.Pod {
+				pod := makeTestPod("4")
+				pod.Spec.Containers[0].Resources = v1.ResourceRequirements{}
+				return pod
+			},
+			expected: `the pod QoS class is BestEffort: container "cnt" sets no requests nor limits`,
+		},
+		{
+			name: "another container is not guaranteed",
+			pod: func() *v1.Pod {
+				pod := makeTestPod("4")
+				pod.Spec.Containers = append(pod.Spec.Containers, v1.Container{
+					Name: "sidecar",
+					Resources: v1.ResourceRequirements{
+						Limits: v1.ResourceList{v1.ResourceCPU: resource.MustParse("1")},
+					},
+				})
+				return pod
+			},
+			expected: `the pod QoS class is Burstable: container "sidecar" has no memory limit`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			pod := tc.pod()
+			got := SharedReason(pod, &pod.Spec.Containers[0])
+			if got != tc.expected {
+				t.Errorf("got %q expected %q", got, tc.expected)
+			}
+		})
+	}
+}
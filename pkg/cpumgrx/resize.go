@@ -0,0 +1,112 @@
+/*
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2026 Red Hat, Inc.
+ */
+
+package cpumgrx
+
+import (
+	"fmt"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	v1qos "k8s.io/kubernetes/pkg/apis/core/v1/helper/qos"
+	"k8s.io/utils/cpuset"
+)
+
+// ResizeResult is the outcome of an in-place resize of a container.
+type ResizeResult struct {
+	AdmitResult
+	Container string `json:"container"`
+	// RequestedCPUs is the amount of exclusive CPUs the container should have after the resize, 0 if none.
+	RequestedCPUs int `json:"requestedCPUs"`
+	// ExclusiveCPUs is the amount of exclusive CPUs the container has after the resize, 0 if none.
+	ExclusiveCPUs int           `json:"exclusiveCPUs"`
+	Before        cpuset.CPUSet `json:"before"`
+	After         cpuset.CPUSet `json:"after"`
+}
+
+// IsConsistent tells if the exclusive CPUs of the container match its new request.
+// The static policy never changes the CPUs of a container already allocated,
+// so a resize changing the amount of exclusive CPUs can be admitted but not consistent.
+func (rr ResizeResult) IsConsistent() bool {
+	if !rr.Admit {
+		return true
+	}
+	return rr.ExclusiveCPUs == rr.RequestedCPUs
+}
+
+// Resize changes in place the CPU request (and limit, if the container is guaranteed)
+// of the given container of an admitted pod, then runs the pod through the admission
+// again, like the kubelet does to check if a resize can be accommodated. Returns the
+// resized pod if admitted, the unchanged pod if the resize is deferred. Unlike Admit, a rejection doesn't release
+// anything: the pod keeps running with its current resources.
+// Requires the InPlacePodVerticalScaling feature gate to make the static policy consider
+// the resources allocated to the container.
+func (cmx *CpuMgrx) Resize(pod *v1.Pod, containerName string, cpus resource.Quantity) (*v1.Pod, ResizeResult, error) {
+	idx := -1
+	for i := range pod.Spec.Containers {
+		if pod.Spec.Containers[i].Name == containerName {
+			idx = i
+			break
+		}
+	}
+	if idx == -1 {
+		return pod, ResizeResult{}, fmt.Errorf("pod %q has no container %q", pod.Name, containerName)
+	}
+	if _, err := cmx.containers.GetContainerID(string(pod.UID), containerName); err != nil {
+		return pod, ResizeResult{}, fmt.Errorf("pod %q is not admitted", pod.Name)
+	}
+
+	resized := pod.DeepCopy()
+	cnt := &resized.Spec.Containers[idx]
+	if cnt.Resources.Requests == nil {
+		cnt.Resources.Requests = make(v1.ResourceList)
+	}
+	if v1qos.GetPodQOS(pod) == v1.PodQOSGuaranteed {
+		cnt.Resources.Limits[v1.ResourceCPU] = cpus
+	}
+	cnt.Resources.Requests[v1.ResourceCPU] = cpus
+	if limit, ok := cnt.Resources.Limits[v1.ResourceCPU]; ok && cpus.Cmp(limit) > 0 {
+		return pod, ResizeResult{}, fmt.Errorf("pod %q container %q: cpu request %s exceeds limit %s", pod.Name, containerName, cpus.String(), limit.String())
+	}
+	if v1qos.GetPodQOS(resized) != v1qos.GetPodQOS(pod) {
+		// the API server would not even accept this
+		return pod, ResizeResult{}, fmt.Errorf("pod %q: resize would change the QoS class", pod.Name)
+	}
+
+	// once the resize is accepted, the kubelet reports the new allocated resources.
+	resized.Status.ContainerStatuses = nil
+	for _, c := range resized.Spec.Containers {
+		resized.Status.ContainerStatuses = append(resized.Status.ContainerStatuses, v1.ContainerStatus{
+			Name:               c.Name,
+			AllocatedResources: c.Resources.Requests.DeepCopy(),
+		})
+	}
+
+	rr := ResizeResult{
+		Container:     containerName,
+		RequestedCPUs: guaranteedCPUs(resized, cnt),
+		Before:        cmx.cpuMgr.State().GetCPUSetOrDefault(string(pod.UID), containerName),
+	}
+	rr.AdmitResult = cmx.admit(resized)
+	rr.After = cmx.cpuMgr.State().GetCPUSetOrDefault(string(pod.UID), containerName)
+	if _, ok := cmx.cpuMgr.State().GetCPUSet(string(pod.UID), containerName); ok {
+		rr.ExclusiveCPUs = rr.After.Size()
+	}
+	if !rr.Admit {
+		return pod, rr, nil
+	}
+	return resized, rr, nil
+}
@@ -0,0 +1,154 @@
+/*
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2026 Red Hat, Inc.
+ */
+
+package cpumgrx
+
+import (
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	utilfeature "k8s.io/apiserver/pkg/util/feature"
+	featuregatetesting "k8s.io/component-base/featuregate/testing"
+	"k8s.io/kubernetes/pkg/features"
+	"k8s.io/utils/cpuset"
+
+	"github.com/ffromani/cpumgrx/internal/testutil"
+)
+
+func TestResize(t *testing.T) {
+	testCases := []struct {
+		name       string
+		tmPolicy   string
+		cpus       string
+		admit      bool
+		consistent bool
+	}{
+		{
+			name:       "same size",
+			tmPolicy:   "single-numa-node",
+			cpus:       "4",
+			admit:      true,
+			consistent: true,
+		},
+		{
+			name:     "grow single-numa-node",
+			tmPolicy: "single-numa-node",
+			cpus:     "6",
+			admit:    false,
+		},
+		{
+			name:     "grow none",
+			tmPolicy: "none",
+			cpus:     "6",
+			admit:    true,
+		},
+		{
+			name:     "to shared none",
+			tmPolicy: "none",
+			cpus:     "3500m",
+			admit:    true,
+		},
+	}
+
+	featuregatetesting.SetFeatureGateDuringTest(t, utilfeature.DefaultFeatureGate, features.InPlacePodVerticalScaling, true)
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mgrx, err := NewFromParams(Params{
+				PolicyName:         "static",
+				TMPolicyName:       tc.tmPolicy,
+				MachineInfo:        testutil.ReadMachineInfo(t, "../../examples/machineinfo-v49-ryzen5950x.json"),
+				ReservedCPUQty:     resource.MustParse("1"),
+				ReservedCPUSet:     cpuset.New(0),
+				StateFileDirectory: t.TempDir(),
+			})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			pod := makeTestPod("4")
+			if res := mgrx.Admit(pod); !res.Admit {
+				t.Fatalf("pod rejected: %v", res)
+			}
+
+			got, rr, err := mgrx.Resize(pod, "cnt", resource.MustParse(tc.cpus))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if rr.Admit != tc.admit {
+				t.Errorf("admit got %v expected %v (%v)", rr.Admit, tc.admit, rr)
+			}
+			if rr.IsConsistent() != tc.consistent && tc.admit {
+				t.Errorf("consistent got %v expected %v (%+v)", rr.IsConsistent(), tc.consistent, rr)
+			}
+			if !rr.After.Equals(rr.Before) {
+				t.Errorf("exclusive CPUs changed: %v -> %v", rr.Before, rr.After)
+			}
+			if tc.admit == (got == pod) {
+				t.Errorf("unexpected pod returned")
+			}
+		})
+	}
+}
+
+func makeTestPod(cpus string) *v1.Pod {
+	res := v1.ResourceList{
+		v1.ResourceCPU:    resource.MustParse(cpus),
+		v1.ResourceMemory: resource.MustParse("1Gi"),
+	}
+	pod := v1.Pod{
+		Spec: v1.PodSpec{
+			Containers: []v1.Container{
+				{
+					Name: "cnt",
+					Resources: v1.ResourceRequirements{
+						Limits:   res.DeepCopy(),
+						Requests: res.DeepCopy(),
+					},
+				},
+			},
+		},
+	}
+	pod.Name = "test-pod"
+	pod.UID = "test-pod-uid"
+	return &pod
+}
+
+func TestResizeNotAdmitted(t *testing.T) {
+	featuregatetesting.SetFeatureGateDuringTest(t, utilfeature.DefaultFeatureGate, features.InPlacePodVerticalScaling, true)
+	mgrx, err := NewFromParams(Params{
+		PolicyName:     "static",
+		MachineInfo:    testutil.ReadMachineInfo(t, "../../examples/machineinfo-v49-ryzen5950x.json"),
+		ReservedCPUQty: resource.MustParse("1"),
+		ReservedCPUSet: cpuset.New(0),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	rejected := makeTestPod("40")
+	if res := mgrx.Admit(rejected); res.Admit {
+		t.Fatalf("pod admitted: %v", res)
+	}
+	freeCPUs := mgrx.GetFreeCPUs()
+	for _, pod := range []*v1.Pod{makeTestPod("4"), rejected} {
+		if _, _, err := mgrx.Resize(pod, "cnt", resource.MustParse("2")); err == nil {
+			t.Errorf("resized a pod never admitted")
+		}
+	}
+	if got := mgrx.GetFreeCPUs(); !got.Equals(freeCPUs) {
+		t.Errorf("free CPUs got %v expected %v", got, freeCPUs)
+	}
+}
@@ -0,0 +1,165 @@
+/*
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2026 Red Hat, Inc.
+ */
+
+package cpumgrx
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/kubernetes/pkg/kubelet/cm/cpumanager/state"
+	"k8s.io/utils/cpuset"
+)
+
+// StateFileName is the name of the checkpoint the cpu manager keeps in its state directory.
+const StateFileName = "cpu_manager_state"
+
+// Assignment is what a container had before a kubelet restart, and what it has after.
+type Assignment struct {
+	Pod       string        `json:"pod"`
+	Container string        `json:"container"`
+	Before    cpuset.CPUSet `json:"before"`
+	After     cpuset.CPUSet `json:"after"`
+}
+
+// PodRejection is a pod the restarted kubelet refuses to admit again.
+type PodRejection struct {
+	Pod string `json:"pod"`
+	AdmitResult
+}
+
+// RestartReport tells how the exclusive CPU assignments fared across a kubelet restart.
+type RestartReport struct {
+	// Refused is why the kubelet would not start, like a state validation error.
+	// The other fields are empty then: the node must be drained and the state removed.
+	Refused string `json:"refused,omitempty"`
+	// Kept are the assignments found in the checkpoint which survived.
+	Kept []Assignment `json:"kept,omitempty"`
+	// Changed are the containers which got different CPUs, or got exclusive CPUs only now.
+	Changed []Assignment `json:"changed,omitempty"`
+	// Dropped are the assignments released because their pods were rejected, or were not
+	// given at all. The latter are named after their pod UID, the only thing the checkpoint has.
+	Dropped []Assignment `json:"dropped,omitempty"`
+	// Rejected are the pods which failed the admission after the restart.
+	Rejected []PodRejection `json:"rejected,omitempty"`
+}
+
+// Restart simulates a kubelet restart, possibly with a changed configuration or machine:
+// a new manager built out of the params starts from the state of this manager, knowing
+// about its containers, and admits again the pods, in order, like the kubelet does with
+// the pods found running. The pods not given are gone, and their CPUs are released.
+// With a state directory, the state goes through the checkpoint file there. The new
+// manager is nil if the kubelet would refuse to start. This manager is left as it was.
+func (cmx *CpuMgrx) Restart(params Params, pods []*v1.Pod) (*CpuMgrx, RestartReport, error) {
+	rp := RestartReport{}
+	before := cmx.cpuMgr.State().GetCPUAssignments()
+	var restarted *CpuMgrx
+	var err error
+	if params.StateFileDirectory != "" {
+		if err := cmx.saveCheckpoint(filepath.Join(params.StateFileDirectory, StateFileName)); err != nil {
+			return nil, rp, err
+		}
+		restarted, err = newFromParams(params, cmx.containers.Clone(), nil)
+	} else if params.PolicyName != cmx.policyName {
+		// what restoring the checkpoint catches before the policy gets to see the state
+		err = fmt.Errorf("could not restore state from checkpoint: configured policy %q differs from state checkpoint policy %q, please drain this node and delete the CPU manager checkpoint file before restarting Kubelet", params.PolicyName, cmx.policyName)
+	} else {
+		restarted, err = newFromParams(params, cmx.containers.Clone(), cmx.cloneState())
+	}
+	if err != nil {
+		rp.Refused = err.Error()
+		return nil, rp, nil
+	}
+
+	for _, pod := range pods {
+		if ar := restarted.Admit(pod); !ar.Admit {
+			rp.Rejected = append(rp.Rejected, PodRejection{Pod: pod.Name, AdmitResult: ar})
+		}
+		for _, cnt := range allContainers(pod) {
+			prev, hadCPUs := before[string(pod.UID)][cnt.Name]
+			cur, hasCPUs := restarted.cpuMgr.State().GetCPUSet(string(pod.UID), cnt.Name)
+			asg := Assignment{Pod: pod.Name, Container: cnt.Name, Before: prev, After: cur}
+			switch {
+			case !hadCPUs && !hasCPUs:
+				continue
+			case !hasCPUs:
+				rp.Dropped = append(rp.Dropped, asg)
+			case hadCPUs && prev.Equals(cur):
+				rp.Kept = append(rp.Kept, asg)
+			default:
+				rp.Changed = append(rp.Changed, asg)
+			}
+		}
+	}
+
+	// the kubelet releases the assignments of the pods not running anymore as stale state,
+	// once it knows about all the pods
+	given := make(map[string]bool, len(pods))
+	for _, pod := range pods {
+		given[string(pod.UID)] = true
+	}
+	for _, podUID := range slices.Sorted(maps.Keys(before)) {
+		if given[podUID] {
+			continue
+		}
+		for _, cntName := range slices.Sorted(maps.Keys(before[podUID])) {
+			if err := restarted.releaseStale(podUID, cntName); err != nil {
+				return nil, rp, err
+			}
+			rp.Dropped = append(rp.Dropped, Assignment{Pod: podUID, Container: cntName, Before: before[podUID][cntName]})
+		}
+	}
+	return restarted, rp, nil
+}
+
+// releaseStale releases the CPUs of a container of a pod which is gone.
+func (cmx *CpuMgrx) releaseStale(podUID, containerName string) error {
+	cntID, err := cmx.containers.GetContainerID(podUID, containerName)
+	if err != nil {
+		// the manager releases only the containers it knows about
+		pod := &v1.Pod{}
+		pod.UID = types.UID(podUID)
+		cnt := &v1.Container{Name: containerName}
+		cntID = makeContainerID(pod, cnt)
+		cmx.addContainer(pod, cnt, cntID)
+	}
+	return cmx.removeContainer(cntID)
+}
+
+// cloneState returns a copy of the state, like the kubelet finds it in the checkpoint.
+func (cmx *CpuMgrx) cloneState() state.State {
+	st := state.NewMemoryState()
+	st.SetDefaultCPUSet(cmx.cpuMgr.State().GetDefaultCPUSet())
+	st.SetCPUAssignments(cmx.cpuMgr.State().GetCPUAssignments())
+	return st
+}
+
+func (cmx *CpuMgrx) saveCheckpoint(path string) error {
+	dst, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := cmx.WriteCheckpoint(dst); err != nil {
+		dst.Close()
+		return err
+	}
+	return dst.Close()
+}
@@ -0,0 +1,131 @@
+/*
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2026 Red Hat, Inc.
+ */
+
+package cpumgrx
+
+import (
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/cpuset"
+
+	"github.com/ffromani/cpumgrx/internal/testutil"
+)
+
+func TestRestart(t *testing.T) {
+	params := Params{
+		PolicyName:     "static",
+		MachineInfo:    testutil.ReadMachineInfo(t, "../../examples/machineinfo-v49-ryzen5950x.json"),
+		ReservedCPUQty: resource.MustParse("1"),
+		ReservedCPUSet: cpuset.New(0),
+	}
+
+	testCases := []struct {
+		name     string
+		change   func(params *Params)
+		gone     bool
+		refused  bool
+		kept     int
+		dropped  int
+		rejected int
+	}{
+		{
+			name:   "unchanged",
+			change: func(params *Params) {},
+			kept:   2,
+		},
+		{
+			name:    "pod gone",
+			change:  func(params *Params) {},
+			gone:    true,
+			kept:    1,
+			dropped: 1,
+		},
+		{
+			name: "full-pcpus-only added",
+			change: func(params *Params) {
+				params.CPUPolicyOptions = map[string]string{"full-pcpus-only": "true"}
+			},
+			kept:     1,
+			dropped:  1,
+			rejected: 1,
+		},
+		{
+			name: "policy changed",
+			change: func(params *Params) {
+				params.PolicyName = "none"
+			},
+			refused: true,
+		},
+		{
+			name: "reserved CPUs taken",
+			change: func(params *Params) {
+				params.ReservedCPUQty = resource.MustParse("2")
+				params.ReservedCPUSet = cpuset.New(0, 1)
+			},
+			refused: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mgrx, err := NewFromParams(params)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			defer mgrx.Close()
+			even := makeTestPod("4")
+			odd := makeTestPod("3")
+			odd.Name, odd.UID = "odd-pod", types.UID("odd-pod-uid")
+			pods := []*v1.Pod{even, odd}
+			for _, pod := range pods {
+				if res := mgrx.Admit(pod); !res.Admit {
+					t.Fatalf("pod %q rejected: %v", pod.Name, res)
+				}
+			}
+			before := mgrx.GetCPUs(odd)
+
+			newParams := params
+			tc.change(&newParams)
+			running := pods
+			if tc.gone {
+				running = pods[:1]
+			}
+			restarted, rp, err := mgrx.Restart(newParams, running)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if restarted != nil {
+				defer restarted.Close()
+			}
+			if refused := rp.Refused != ""; refused != tc.refused || refused != (restarted == nil) {
+				t.Fatalf("got refused %q with manager %v, expected refused=%v", rp.Refused, restarted, tc.refused)
+			}
+			if len(rp.Kept) != tc.kept || len(rp.Dropped) != tc.dropped || len(rp.Rejected) != tc.rejected || len(rp.Changed) != 0 {
+				t.Errorf("unexpected report: %+v", rp)
+			}
+			if tc.gone && !before.IsSubsetOf(restarted.GetFreeCPUs()) {
+				t.Errorf("CPUs of the pod gone not released: free %v", restarted.GetFreeCPUs())
+			}
+			// the manager restarted from keeps working on its own
+			if got := mgrx.GetCPUs(odd); !got.Equals(before) {
+				t.Errorf("old manager changed: got %v expected %v", got, before)
+			}
+		})
+	}
+}
@@ -0,0 +1,155 @@
+/*
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2026 Red Hat, Inc.
+ */
+
+package cpumgrx
+
+import (
+	"fmt"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/kubernetes/pkg/kubelet/cm/topologymanager"
+	"k8s.io/utils/cpuset"
+
+	"github.com/ffromani/cpumgrx/pkg/klogcapture"
+)
+
+// ContainerResult is what a container of an admitted pod got.
+type ContainerResult struct {
+	Name string `json:"name"`
+	Init bool   `json:"init,omitempty"`
+	// CPUs are the CPUs the container can run on, exclusive or shared.
+	CPUs      cpuset.CPUSet `json:"cpus"`
+	Exclusive bool          `json:"exclusive"`
+	// SharedReason tells why the container got no exclusive CPUs.
+	SharedReason string `json:"sharedReason,omitempty"`
+	// Affinity is the topology manager hint the CPUs were allocated with.
+	Affinity topologymanager.TopologyHint `json:"affinity"`
+	// NUMANodes, Sockets and UncoreCaches are the IDs of the ones the CPUs belong to.
+	NUMANodes    cpuset.CPUSet `json:"numaNodes"`
+	Sockets      cpuset.CPUSet `json:"sockets"`
+	UncoreCaches cpuset.CPUSet `json:"uncoreCaches"`
+}
+
+// Result is the outcome of Run. The containers are set only if the pod was admitted,
+// init containers first, in the pod spec order.
+type Result struct {
+	AdmitResult
+	QOSClass   v1.PodQOSClass    `json:"qosClass"`
+	Containers []ContainerResult `json:"containers,omitempty"`
+	// Log is what the managers logged meanwhile, in order, if captured.
+	// The entries tell the container they are about, if any.
+	Log []klogcapture.Entry `json:"log,omitempty"`
+}
+
+// rejection holds what the typed errors share.
+type rejection struct {
+	AdmitResult
+}
+
+func (r rejection) Error() string {
+	return r.AdmitResult.String()
+}
+
+// SMTAlignmentError means the pod asked for CPUs which cannot be full physical cores.
+type SMTAlignmentError struct{ rejection }
+
+// TopologyAffinityError means the topology manager policy could not be satisfied.
+type TopologyAffinityError struct{ rejection }
+
+// InsufficientCPUsError means there were not enough free CPUs left.
+type InsufficientCPUsError struct{ rejection }
+
+// InvalidRequestError means the pod was malformed, and never reached the managers.
+type InvalidRequestError struct{ rejection }
+
+// UnexpectedError covers all the other rejections.
+type UnexpectedError struct{ rejection }
+
+func rejectionError(ar AdmitResult) error {
+	rej := rejection{AdmitResult: ar}
+	switch ar.Cause {
+	case CauseSMTAlignment:
+		return &SMTAlignmentError{rej}
+	case CauseTopologyAffinity:
+		return &TopologyAffinityError{rej}
+	case CauseInsufficientCPUs:
+		return &InsufficientCPUsError{rej}
+	case CauseInvalidRequest:
+		return &InvalidRequestError{rej}
+	default:
+		return &UnexpectedError{rej}
+	}
+}
+
+// validatePod rejects what the API server would have never let through.
+func validatePod(pod *v1.Pod) error {
+	if pod.UID == "" {
+		return fmt.Errorf("pod %q has no UID", pod.Name)
+	}
+	if len(pod.Spec.Containers) == 0 {
+		return fmt.Errorf("pod %q has no containers", pod.Name)
+	}
+	names := sets.New[string]()
+	for _, cnt := range allContainers(pod) {
+		if names.Has(cnt.Name) {
+			return fmt.Errorf("pod %q has duplicate container %q", pod.Name, cnt.Name)
+		}
+		names.Insert(cnt.Name)
+		req, hasReq := cnt.Resources.Requests[v1.ResourceCPU]
+		lim, hasLim := cnt.Resources.Limits[v1.ResourceCPU]
+		if hasReq && hasLim && req.Cmp(lim) > 0 {
+			return fmt.Errorf("pod %q container %q cpu request %s exceeds limit %s", pod.Name, cnt.Name, req.String(), lim.String())
+		}
+	}
+	return nil
+}
+
+// DescribeContainers tells what all the containers of an admitted pod got,
+// init containers first, in the pod spec order, like Run does.
+func (cmx *CpuMgrx) DescribeContainers(pod *v1.Pod) []ContainerResult {
+	var res []ContainerResult
+	for _, cnt := range pod.Spec.InitContainers {
+		res = append(res, cmx.describeContainer(pod, &cnt, true))
+	}
+	for _, cnt := range pod.Spec.Containers {
+		res = append(res, cmx.describeContainer(pod, &cnt, false))
+	}
+	return res
+}
+
+func (cmx *CpuMgrx) describeContainer(pod *v1.Pod, cnt *v1.Container, init bool) ContainerResult {
+	podUID := string(pod.UID)
+	cpus := cmx.cpuMgr.State().GetCPUSetOrDefault(podUID, cnt.Name)
+	details := cmx.topo.CPUDetails.KeepOnly(cpus)
+	cr := ContainerResult{
+		Name:         cnt.Name,
+		Init:         init,
+		CPUs:         cpus,
+		Exclusive:    !cmx.cpuMgr.GetExclusiveCPUs(podUID, cnt.Name).IsEmpty(),
+		Affinity:     cmx.fakeTm.GetAffinity(podUID, cnt.Name),
+		NUMANodes:    details.NUMANodes(),
+		Sockets:      details.Sockets(),
+		UncoreCaches: details.UncoreCaches(),
+	}
+	if cmx.topoMgr != nil {
+		cr.Affinity = cmx.topoMgr.GetAffinity(podUID, cnt.Name)
+	}
+	if !cr.Exclusive {
+		cr.SharedReason = cmx.SharedReason(pod, cnt)
+	}
+	return cr
+}
@@ -0,0 +1,125 @@
+/*
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2026 Red Hat, Inc.
+ */
+
+package cpumgrx
+
+import (
+	"errors"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/utils/cpuset"
+
+	"github.com/ffromani/cpumgrx/internal/testutil"
+)
+
+func TestRun(t *testing.T) {
+	testCases := []struct {
+		name     string
+		pod      func() *v1.Pod
+		checkErr func(err error) bool
+		cpus     int
+		shared   bool
+	}{
+		{
+			name: "exclusive",
+			pod:  func() *v1.Pod { return makeTestPod("4") },
+			cpus: 4,
+		},
+		{
+			name: "shared",
+			pod:  func() *v1.Pod { return makeTestPod("1500m") },
+			// the shared pool includes the reserved CPUs
+			cpus:   32,
+			shared: true,
+		},
+		{
+			name: "smt alignment",
+			pod:  func() *v1.Pod { return makeTestPod("3") },
+			checkErr: func(err error) bool {
+				var target *SMTAlignmentError
+				return errors.As(err, &target) && target.Cause == CauseSMTAlignment
+			},
+		},
+		{
+			name: "topology affinity",
+			pod:  func() *v1.Pod { return makeTestPod("40") },
+			checkErr: func(err error) bool {
+				var target *TopologyAffinityError
+				return errors.As(err, &target)
+			},
+		},
+		{
+			name: "invalid request",
+			pod: func() *v1.Pod {
+				pod := makeTestPod("4")
+				pod.Spec.Containers[0].Resources.Requests[v1.ResourceCPU] = resource.MustParse("8")
+				return pod
+			},
+			checkErr: func(err error) bool {
+				var target *InvalidRequestError
+				return errors.As(err, &target)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			params := Params{
+				PolicyName:       "static",
+				TMPolicyName:     "single-numa-node",
+				CPUPolicyOptions: map[string]string{"full-pcpus-only": "true"},
+				MachineInfo:      testutil.ReadMachineInfo(t, "../../examples/machineinfo-v49-ryzen5950x.json"),
+				ReservedCPUQty:   resource.MustParse("1"),
+				ReservedCPUSet:   cpuset.New(0),
+			}
+			mgrx, err := NewFromParams(params)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			defer mgrx.Close()
+
+			res, err := mgrx.Run(tc.pod())
+			if tc.checkErr != nil {
+				if err == nil || !tc.checkErr(err) {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if res.Admit || len(res.Containers) > 0 {
+					t.Errorf("rejected pod with result: %+v", res)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(res.Containers) != 1 {
+				t.Fatalf("unexpected containers: %+v", res.Containers)
+			}
+			cr := res.Containers[0]
+			if cr.Name != "cnt" || cr.CPUs.Size() != tc.cpus || cr.Exclusive == tc.shared {
+				t.Errorf("unexpected container result: %+v", cr)
+			}
+			if !cr.NUMANodes.Equals(cpuset.New(0)) || !cr.Sockets.Equals(cpuset.New(0)) {
+				t.Errorf("unexpected topology breakdown: %+v", cr)
+			}
+			// single-numa-node reports no mask when all the NUMA nodes fit, like here
+			if !cr.Affinity.Preferred {
+				t.Errorf("unexpected affinity: %v", cr.Affinity)
+			}
+		})
+	}
+}
@@ -0,0 +1,116 @@
+/*
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2026 Red Hat, Inc.
+ */
+
+package cpumgrx
+
+import (
+	"errors"
+	"io"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/kubernetes/pkg/kubelet/cm/containermap"
+	"k8s.io/kubernetes/pkg/kubelet/cm/cpumanager/state"
+	"k8s.io/utils/cpuset"
+)
+
+// Snapshot is the cpu manager state at a point in time. Snapshots are independent
+// from the manager which took them, and can be restored any number of times.
+type Snapshot struct {
+	DefaultCPUSet cpuset.CPUSet
+	Assignments   state.ContainerCPUAssignments
+	// Containers maps the container IDs to the pods and containers they belong to,
+	// so the pods admitted before the snapshot can still be removed once restored.
+	Containers containermap.ContainerMap
+}
+
+// Snapshot captures the current state of the manager.
+func (cmx *CpuMgrx) Snapshot() Snapshot {
+	st := cmx.cpuMgr.State()
+	return Snapshot{
+		DefaultCPUSet: st.GetDefaultCPUSet(),
+		Assignments:   st.GetCPUAssignments(),
+		Containers:    cmx.containers.Clone(),
+	}
+}
+
+// Restore brings the manager back to the given snapshot, forgetting about all
+// the pods admitted after it was taken. The snapshot can be reused afterwards.
+func (cmx *CpuMgrx) Restore(snap Snapshot) error {
+	st, ok := cmx.cpuMgr.State().(state.State)
+	if !ok {
+		return errors.New("cpu manager state is not writable")
+	}
+
+	// first forget the containers which came later, the state is overwritten anyway
+	var stale []string
+	cmx.containers.Visit(func(podUID, containerName, containerID string) {
+		if _, _, err := snap.Containers.GetContainerRef(containerID); err != nil {
+			stale = append(stale, containerID)
+		}
+	})
+	for _, cntID := range stale {
+		if err := cmx.removeContainer(cntID); err != nil {
+			return err
+		}
+	}
+
+	st.SetCPUAssignments(snap.Assignments.Clone())
+	st.SetDefaultCPUSet(snap.DefaultCPUSet.Clone())
+
+	// then make the managers know again about the containers removed meanwhile
+	snap.Containers.Visit(func(podUID, containerName, containerID string) {
+		// the managers only care about the identity of the pod and the container
+		pod := &v1.Pod{}
+		pod.UID = types.UID(podUID)
+		cmx.addContainer(pod, &v1.Container{Name: containerName}, containerID)
+	})
+	return nil
+}
+
+// NewFromSnapshot creates a new manager with the state found in the snapshot.
+// The params must describe the same node the snapshot was taken on.
+func NewFromSnapshot(params Params, snap Snapshot) (*CpuMgrx, error) {
+	cmx, err := NewFromParams(params)
+	if err != nil {
+		return nil, err
+	}
+	if err := cmx.Restore(snap); err != nil {
+		cmx.Close()
+		return nil, err
+	}
+	return cmx, nil
+}
+
+// WriteCheckpoint writes the current state like the kubelet cpu_manager_state file.
+func (cmx *CpuMgrx) WriteCheckpoint(w io.Writer) error {
+	st := cmx.cpuMgr.State()
+	cp := state.NewCPUManagerCheckpoint()
+	cp.PolicyName = cmx.policyName
+	cp.DefaultCPUSet = st.GetDefaultCPUSet().String()
+	for podUID, cnts := range st.GetCPUAssignments() {
+		cp.Entries[podUID] = make(map[string]string, len(cnts))
+		for cntName, cpus := range cnts {
+			cp.Entries[podUID][cntName] = cpus.String()
+		}
+	}
+	data, err := cp.MarshalCheckpoint()
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
@@ -0,0 +1,226 @@
+/*
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2026 Red Hat, Inc.
+ */
+
+package cpumgrx
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/cpuset"
+
+	"github.com/ffromani/cpumgrx/internal/testutil"
+)
+
+func TestSnapshotRestore(t *testing.T) {
+	params := Params{
+		PolicyName:     "static",
+		TMPolicyName:   "single-numa-node",
+		MachineInfo:    testutil.ReadMachineInfo(t, "../../examples/machineinfo-v49-ryzen5950x.json"),
+		ReservedCPUQty: resource.MustParse("1"),
+		ReservedCPUSet: cpuset.New(0),
+	}
+	params.StateFileDirectory = t.TempDir()
+	mgrx, err := NewFromParams(params)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	base := makeTestPod("4")
+	base.UID = "base-uid"
+	if res := mgrx.Admit(base); !res.Admit {
+		t.Fatalf("pod rejected: %v", res)
+	}
+	snap := mgrx.Snapshot()
+	freeCPUs := mgrx.GetFreeCPUs()
+
+	// branch: the same candidate must get the same CPUs from the same starting point
+	var candidateCPUs cpuset.CPUSet
+	for round := 0; round < 2; round++ {
+		candidate := makeTestPod("8")
+		candidate.UID = types.UID("candidate-uid")
+		if res := mgrx.Admit(candidate); !res.Admit {
+			t.Fatalf("round %d: candidate rejected: %v", round, res)
+		}
+		cpus := mgrx.GetCPUs(candidate)
+		if round > 0 && !cpus.Equals(candidateCPUs) {
+			t.Errorf("round %d: candidate got %v expected %v", round, cpus, candidateCPUs)
+		}
+		candidateCPUs = cpus
+		if err := mgrx.Restore(snap); err != nil {
+			t.Fatalf("round %d: restore failed: %v", round, err)
+		}
+		if got := mgrx.GetFreeCPUs(); !got.Equals(freeCPUs) {
+			t.Errorf("round %d: free CPUs got %v expected %v", round, got, freeCPUs)
+		}
+	}
+
+	// a pod removed after the snapshot must be removable again once restored
+	if err := mgrx.Remove(base); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := mgrx.Restore(snap); err != nil {
+		t.Fatalf("restore failed: %v", err)
+	}
+	if got := mgrx.GetCPUs(base); got.Size() != 4 {
+		t.Errorf("base pod CPUs not restored: %v", got)
+	}
+	if err := mgrx.Remove(base); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := mgrx.GetFreeCPUs(); !got.Equals(freeCPUs.Union(snap.Assignments[string(base.UID)]["cnt"])) {
+		t.Errorf("base pod CPUs not released: free %v", got)
+	}
+
+	params.StateFileDirectory = t.TempDir()
+	other, err := NewFromSnapshot(params, snap)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := other.GetFreeCPUs(); !got.Equals(freeCPUs) {
+		t.Errorf("new manager free CPUs got %v expected %v", got, freeCPUs)
+	}
+}
+
+func TestSnapshotContainers(t *testing.T) {
+	for _, stateFile := range []bool{false, true} {
+		t.Run(fmt.Sprintf("stateFile=%v", stateFile), func(t *testing.T) {
+			params := Params{
+				PolicyName:     "static",
+				MachineInfo:    testutil.ReadMachineInfo(t, "../../examples/machineinfo-v49-ryzen5950x.json"),
+				ReservedCPUQty: resource.MustParse("1"),
+				ReservedCPUSet: cpuset.New(0),
+			}
+			if stateFile {
+				params.StateFileDirectory = t.TempDir()
+			}
+			mgrx, err := NewFromParams(params)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			defer mgrx.Close()
+
+			pod := testutil.MakePod("pod", "2", "4")
+			if res := mgrx.Admit(pod); !res.Admit {
+				t.Fatalf("pod rejected: %v", res)
+			}
+			rejected := testutil.MakePod("rejected", "2", "64")
+			if res := mgrx.Admit(rejected); res.Admit {
+				t.Fatalf("pod admitted: %v", res)
+			}
+			snap := mgrx.Snapshot()
+			for _, cnt := range pod.Spec.Containers {
+				if _, _, err := snap.Containers.GetContainerRef(makeContainerID(pod, &cnt)); err != nil {
+					t.Errorf("container %q missing from the snapshot", cnt.Name)
+				}
+			}
+			for _, cnt := range rejected.Spec.Containers {
+				if _, _, err := snap.Containers.GetContainerRef(makeContainerID(rejected, &cnt)); err == nil {
+					t.Errorf("container %q of the rejected pod in the snapshot", cnt.Name)
+				}
+			}
+
+			if err := mgrx.Remove(pod); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := len(mgrx.Snapshot().Containers); got != 0 {
+				t.Errorf("got %d containers after the removal", got)
+			}
+		})
+	}
+}
+
+func TestWriteCheckpoint(t *testing.T) {
+	params := Params{
+		PolicyName:     "static",
+		MachineInfo:    testutil.ReadMachineInfo(t, "../../examples/machineinfo-v49-ryzen5950x.json"),
+		ReservedCPUQty: resource.MustParse("1"),
+		ReservedCPUSet: cpuset.New(0),
+	}
+	mgrx, err := NewFromParams(params)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer mgrx.Close()
+	pod := makeTestPod("4")
+	if res := mgrx.Admit(pod); !res.Admit {
+		t.Fatalf("pod rejected: %v", res)
+	}
+
+	// the kubelet, hence a manager given a state directory, must accept the checkpoint
+	params.StateFileDirectory = t.TempDir()
+	dst, err := os.Create(filepath.Join(params.StateFileDirectory, StateFileName))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := mgrx.WriteCheckpoint(dst); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	dst.Close()
+
+	loaded, err := NewFromParams(params)
+	if err != nil {
+		t.Fatalf("cannot load the checkpoint: %v", err)
+	}
+	if got, expected := loaded.GetCPUs(pod), mgrx.GetCPUs(pod); !got.Equals(expected) {
+		t.Errorf("pod CPUs got %v expected %v", got, expected)
+	}
+	if got, expected := loaded.GetFreeCPUs(), mgrx.GetFreeCPUs(); !got.Equals(expected) {
+		t.Errorf("free CPUs got %v expected %v", got, expected)
+	}
+}
+
+func TestStateInMemory(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("TMPDIR", tmpDir)
+	params := Params{
+		PolicyName:     "static",
+		MachineInfo:    testutil.ReadMachineInfo(t, "../../examples/machineinfo-v49-ryzen5950x.json"),
+		ReservedCPUQty: resource.MustParse("1"),
+		ReservedCPUSet: cpuset.New(0),
+	}
+	mgrx, err := NewFromParams(params)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer mgrx.Close()
+	pod := makeTestPod("4")
+	if res := mgrx.Admit(pod); !res.Admit {
+		t.Fatalf("pod rejected: %v", res)
+	}
+	restarted, rp, err := mgrx.Restart(params, []*v1.Pod{pod})
+	if err != nil || rp.Refused != "" {
+		t.Fatalf("restart failed: %v %q", err, rp.Refused)
+	}
+	defer restarted.Close()
+
+	entries, err := os.ReadDir(tmpDir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(entries) > 0 {
+		t.Errorf("files written: %v", entries)
+	}
+	if cwdEntries, _ := os.ReadDir("."); slices.ContainsFunc(cwdEntries, func(ent os.DirEntry) bool { return ent.Name() == StateFileName }) {
+		t.Errorf("checkpoint written in the working directory")
+	}
+}
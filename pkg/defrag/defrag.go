@@ -0,0 +1,224 @@
+/*
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2026 Red Hat, Inc.
+ */
+
+// Package defrag suggests the fewest pods to delete and recreate to make
+// room on a fragmented node, either for a full free NUMA node or for a pod
+// of a given shape. Each suggestion is proven by replaying the deletion and
+// the recreation through the real allocator.
+package defrag
+
+import (
+	"fmt"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+	"k8s.io/kubernetes/pkg/kubelet/cm/cpumanager/topology"
+	"k8s.io/utils/cpuset"
+
+	"github.com/ffromani/cpumgrx/internal/combinations"
+	"github.com/ffromani/cpumgrx/pkg/cpumgrx"
+)
+
+const (
+	DefaultMaxVictims = 3
+)
+
+type Params struct {
+	Manager cpumgrx.Params
+	// MaxVictims is the maximum amount of pods to consider for recreation.
+	MaxVictims int
+	// Target is the pod we want to make room for. If nil, we want a free NUMA node.
+	Target *v1.Pod
+}
+
+type Placement struct {
+	Name string `json:"name"`
+	// CPUs are the exclusive CPUs of all the containers of the pod.
+	CPUs cpuset.CPUSet `json:"cpus"`
+}
+
+type Suggestion struct {
+	// Victims are the pods to delete and recreate, in this order.
+	Victims []string `json:"victims"`
+	// Target is where the target pod lands, if any.
+	Target *Placement `json:"target,omitempty"`
+	// FreeNUMANode is the NUMA node made free, if no target pod was given.
+	FreeNUMANode int `json:"freeNUMANode"`
+	// Recreated is where the victims land once recreated.
+	Recreated []Placement `json:"recreated"`
+}
+
+type Report struct {
+	// AlreadyFits is true if no action is needed at all.
+	AlreadyFits bool `json:"alreadyFits"`
+	// Rejected lists the pods which could not be admitted in the first place
+	// and are thus not part of the current state.
+	Rejected    []string     `json:"rejected,omitempty"`
+	Suggestions []Suggestion `json:"suggestions,omitempty"`
+}
+
+type advisor struct {
+	params Params
+	topo   *topology.CPUTopology
+	// running are the pods admitted in the current state, in admission order
+	running []*v1.Pod
+}
+
+// Run admits the given pods in order to build the current state, then
+// looks for the smallest sets of pods whose recreation achieves the target.
+func Run(params Params, pods []*v1.Pod) (Report, error) {
+	topo, err := topology.Discover(params.Manager.MachineInfo)
+	if err != nil {
+		return Report{}, err
+	}
+	if params.MaxVictims <= 0 {
+		params.MaxVictims = DefaultMaxVictims
+	}
+
+	adv := advisor{
+		params: params,
+		topo:   topo,
+	}
+	rp := Report{}
+
+	// only pods holding exclusive CPUs, in any container, can free space once recreated
+	var candidates []*v1.Pod
+	err = adv.withState(func(mgrx *cpumgrx.CpuMgrx) error {
+		for _, pod := range pods {
+			if _, err := mgrx.Run(pod); err != nil {
+				klog.V(2).Infof("pod %q rejected: %v", pod.Name, err)
+				rp.Rejected = append(rp.Rejected, pod.Name)
+				continue
+			}
+			adv.running = append(adv.running, pod)
+			if !mgrx.GetPodExclusiveCPUs(pod).IsEmpty() {
+				candidates = append(candidates, pod)
+			}
+		}
+		sugg, ok, err := adv.check(mgrx, nil)
+		if err != nil {
+			return err
+		}
+		rp.AlreadyFits = ok
+		if ok {
+			rp.Suggestions = append(rp.Suggestions, sugg)
+		}
+		return nil
+	})
+	if err != nil || rp.AlreadyFits {
+		return rp, err
+	}
+
+	for numVictims := 1; numVictims <= params.MaxVictims && numVictims <= len(candidates); numVictims++ {
+		combinations.Visit(len(candidates), numVictims, func(idxs []int) bool {
+			var victims []*v1.Pod
+			for _, idx := range idxs {
+				victims = append(victims, candidates[idx])
+			}
+			err = adv.withState(func(mgrx *cpumgrx.CpuMgrx) error {
+				if err := adv.replay(mgrx); err != nil {
+					return err
+				}
+				sugg, ok, err := adv.check(mgrx, victims)
+				if ok {
+					rp.Suggestions = append(rp.Suggestions, sugg)
+				}
+				return err
+			})
+			return err == nil
+		})
+		if err != nil {
+			return rp, err
+		}
+		if len(rp.Suggestions) > 0 {
+			break
+		}
+	}
+	return rp, nil
+}
+
+// withState runs fn against a brand new manager, whose state is discarded afterwards.
+func (adv *advisor) withState(fn func(mgrx *cpumgrx.CpuMgrx) error) error {
+	params := adv.params.Manager
+	params.StateFileDirectory = ""
+	mgrx, err := cpumgrx.NewFromParams(params)
+	if err != nil {
+		return err
+	}
+	defer mgrx.Close()
+	return fn(mgrx)
+}
+
+// replay rebuilds the current state
+func (adv *advisor) replay(mgrx *cpumgrx.CpuMgrx) error {
+	for _, pod := range adv.running {
+		if _, err := mgrx.Run(pod); err != nil {
+			return fmt.Errorf("replay of pod %q failed: %w", pod.Name, err)
+		}
+	}
+	return nil
+}
+
+// check deletes the victims, then recreates them after the target pod, if any.
+// Returns true if the target is achieved and all the victims are admitted again.
+func (adv *advisor) check(mgrx *cpumgrx.CpuMgrx, victims []*v1.Pod) (Suggestion, bool, error) {
+	sugg := Suggestion{
+		FreeNUMANode: -1,
+	}
+	for _, pod := range victims {
+		if err := mgrx.Remove(pod); err != nil {
+			return sugg, false, err
+		}
+		sugg.Victims = append(sugg.Victims, pod.Name)
+	}
+
+	if adv.params.Target != nil {
+		if _, err := mgrx.Run(adv.params.Target); err != nil {
+			return sugg, false, nil
+		}
+		sugg.Target = &Placement{
+			Name: adv.params.Target.Name,
+			CPUs: mgrx.GetPodExclusiveCPUs(adv.params.Target),
+		}
+	}
+
+	for _, pod := range victims {
+		if _, err := mgrx.Run(pod); err != nil {
+			return sugg, false, nil
+		}
+		sugg.Recreated = append(sugg.Recreated, Placement{
+			Name: pod.Name,
+			CPUs: mgrx.GetPodExclusiveCPUs(pod),
+		})
+	}
+
+	if adv.params.Target != nil {
+		return sugg, true, nil
+	}
+	sugg.FreeNUMANode = adv.freeNUMANode(mgrx.GetFreeCPUs())
+	return sugg, sugg.FreeNUMANode >= 0, nil
+}
+
+// freeNUMANode returns the first NUMA node whose allocatable CPUs are all free, or -1
+func (adv *advisor) freeNUMANode(free cpuset.CPUSet) int {
+	for _, numaID := range adv.topo.CPUDetails.NUMANodes().List() {
+		cpus := adv.topo.CPUDetails.CPUsInNUMANodes(numaID).Difference(adv.params.Manager.ReservedCPUSet)
+		if !cpus.IsEmpty() && cpus.IsSubsetOf(free) {
+			return numaID
+		}
+	}
+	return -1
+}
@@ -0,0 +1,148 @@
+/*
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2026 Red Hat, Inc.
+ */
+
+package defrag
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/utils/cpuset"
+
+	"github.com/ffromani/cpumgrx/internal/testutil"
+	"github.com/ffromani/cpumgrx/pkg/cpumgrx"
+)
+
+func TestRun(t *testing.T) {
+	testCases := []struct {
+		name        string
+		machineInfo string
+		reserved    cpuset.CPUSet
+		tmPolicy    string
+		pods        []*v1.Pod
+		target      *v1.Pod
+		// expected in the only suggestion
+		alreadyFits  bool
+		victims      []string
+		freeNUMANode int
+	}{
+		{
+			name:         "free NUMA node",
+			machineInfo:  "../../examples/machineinfo-v39-dualxeongold6230.json",
+			reserved:     cpuset.New(0, 40),
+			tmPolicy:     "none",
+			pods:         []*v1.Pod{testutil.MakePod("p1", "14"), testutil.MakePod("p2", "24"), testutil.MakePod("p3", "21")},
+			victims:      []string{"p1", "p2"},
+			freeNUMANode: 0,
+		},
+		{
+			// the first container of p2 runs on the shared pool
+			name:         "free NUMA node, victim with more containers",
+			machineInfo:  "../../examples/machineinfo-v39-dualxeongold6230.json",
+			reserved:     cpuset.New(0, 40),
+			tmPolicy:     "none",
+			pods:         []*v1.Pod{testutil.MakePod("p1", "14"), testutil.MakePod("p2", "500m", "24"), testutil.MakePod("p3", "21")},
+			victims:      []string{"p1", "p2"},
+			freeNUMANode: 0,
+		},
+		{
+			name:         "target",
+			machineInfo:  "../../examples/machineinfo-v49-dualxeongold6230r.json",
+			reserved:     cpuset.New(0, 52),
+			tmPolicy:     "single-numa-node",
+			pods:         []*v1.Pod{testutil.MakePod("a", "26"), testutil.MakePod("b", "20"), testutil.MakePod("c", "26")},
+			target:       testutil.MakePod("t", "30"),
+			victims:      []string{"a"},
+			freeNUMANode: -1,
+		},
+		{
+			name:         "target and victim with more containers",
+			machineInfo:  "../../examples/machineinfo-v49-dualxeongold6230r.json",
+			reserved:     cpuset.New(0, 52),
+			tmPolicy:     "single-numa-node",
+			pods:         []*v1.Pod{testutil.MakePod("a", "500m", "26"), testutil.MakePod("b", "20"), testutil.MakePod("c", "26")},
+			target:       testutil.MakePod("t", "2", "28"),
+			victims:      []string{"a"},
+			freeNUMANode: -1,
+		},
+		{
+			name:         "target already fits",
+			machineInfo:  "../../examples/machineinfo-v49-dualxeongold6230r.json",
+			reserved:     cpuset.New(0, 52),
+			tmPolicy:     "single-numa-node",
+			pods:         []*v1.Pod{testutil.MakePod("a", "26")},
+			target:       testutil.MakePod("t", "30"),
+			alreadyFits:  true,
+			freeNUMANode: -1,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rp, err := Run(Params{
+				Manager: cpumgrx.Params{
+					PolicyName:     "static",
+					TMPolicyName:   tc.tmPolicy,
+					MachineInfo:    testutil.ReadMachineInfo(t, tc.machineInfo),
+					ReservedCPUQty: resource.MustParse(fmt.Sprintf("%d", tc.reserved.Size())),
+					ReservedCPUSet: tc.reserved,
+				},
+				Target: tc.target,
+			}, tc.pods)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if rp.AlreadyFits != tc.alreadyFits || len(rp.Rejected) > 0 || len(rp.Suggestions) != 1 {
+				t.Fatalf("unexpected report: %+v", rp)
+			}
+			sugg := rp.Suggestions[0]
+			if !reflect.DeepEqual(sugg.Victims, tc.victims) {
+				t.Errorf("got victims %v expected %v", sugg.Victims, tc.victims)
+			}
+			if sugg.FreeNUMANode != tc.freeNUMANode {
+				t.Errorf("got free NUMA node %d expected %d", sugg.FreeNUMANode, tc.freeNUMANode)
+			}
+			if tc.target != nil && (sugg.Target == nil || sugg.Target.CPUs.Size() != guaranteedCPUs(tc.target)) {
+				t.Errorf("unexpected target placement: %+v", sugg.Target)
+			}
+			for idx, pl := range sugg.Recreated {
+				if pl.Name != sugg.Victims[idx] {
+					t.Errorf("recreated %q expected %q", pl.Name, sugg.Victims[idx])
+				}
+				for _, pod := range tc.pods {
+					if pod.Name == pl.Name && pl.CPUs.Size() != guaranteedCPUs(pod) {
+						t.Errorf("pod %q recreated with CPUs %v", pl.Name, pl.CPUs)
+					}
+				}
+			}
+		})
+	}
+}
+
+// guaranteedCPUs sums the integer CPU requests of the pod containers.
+func guaranteedCPUs(pod *v1.Pod) int {
+	res := 0
+	for _, cnt := range pod.Spec.Containers {
+		qty := cnt.Resources.Requests[v1.ResourceCPU]
+		if qty.MilliValue()%1000 == 0 {
+			res += int(qty.Value())
+		}
+	}
+	return res
+}
@@ -0,0 +1,187 @@
+/*
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2026 Red Hat, Inc.
+ */
+
+// Package explain tells which phases of the static policy allocation produced
+// an exclusive CPU set. The phases are derived from the topology and the CPUs
+// which were free before and after the allocation, not from the policy itself,
+// so they describe what happened even when the logs don't.
+package explain
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"k8s.io/kubernetes/pkg/kubelet/cm/cpumanager"
+	"k8s.io/kubernetes/pkg/kubelet/cm/cpumanager/topology"
+	"k8s.io/utils/cpuset"
+)
+
+// Phase is a step of the static policy CPU accumulator.
+type Phase string
+
+const (
+	// PhaseNUMANodes takes NUMA nodes whose CPUs were all free
+	PhaseNUMANodes Phase = "whole NUMA nodes"
+	// PhaseSockets takes sockets whose CPUs were all free
+	PhaseSockets Phase = "whole sockets"
+	// PhaseUncoreCaches takes uncore caches whose CPUs were all free, with prefer-align-cpus-by-uncorecache
+	PhaseUncoreCaches Phase = "whole uncore caches"
+	// PhaseCores takes physical cores whose threads were all free
+	PhaseCores Phase = "whole cores"
+	// PhaseUsedCoreThreads fills the cores other containers already use
+	PhaseUsedCoreThreads Phase = "leftover threads of used cores"
+	// PhaseFreeCoreThreads takes some threads of free cores, splitting them
+	PhaseFreeCoreThreads Phase = "leftover threads of free cores"
+)
+
+// Strategy is how the static policy sorts and picks the free CPUs, according to its options.
+type Strategy struct {
+	// SpreadNUMA is distribute-cpus-across-numa: the CPUs are split evenly across the NUMA nodes needed
+	SpreadNUMA bool `json:"spreadNUMA,omitempty"`
+	// SpreadCores is distribute-cpus-across-cores: one thread per core, whole cores are never taken
+	SpreadCores bool `json:"spreadCores,omitempty"`
+	// PreferUncore is prefer-align-cpus-by-uncorecache: whole uncore caches are taken before whole cores
+	PreferUncore bool `json:"preferUncore,omitempty"`
+}
+
+// StrategyFromOptions reads the strategy from the cpu manager policy options.
+func StrategyFromOptions(options map[string]string) (Strategy, error) {
+	st := Strategy{}
+	for name, value := range options {
+		var target *bool
+		switch name {
+		case cpumanager.DistributeCPUsAcrossNUMAOption:
+			target = &st.SpreadNUMA
+		case cpumanager.DistributeCPUsAcrossCoresOption:
+			target = &st.SpreadCores
+		case cpumanager.PreferAlignByUnCoreCacheOption:
+			target = &st.PreferUncore
+		default:
+			continue
+		}
+		val, err := strconv.ParseBool(value)
+		if err != nil {
+			return st, fmt.Errorf("bad value for option %q: %w", name, err)
+		}
+		*target = val
+	}
+	return st, nil
+}
+
+func (st Strategy) String() string {
+	var items []string
+	if st.SpreadNUMA {
+		items = append(items, "spread across NUMA nodes")
+	} else {
+		items = append(items, "packed in NUMA nodes")
+	}
+	if st.SpreadCores {
+		items = append(items, "spread across cores")
+	} else {
+		items = append(items, "packed in cores")
+	}
+	if st.PreferUncore {
+		items = append(items, "uncore caches first")
+	}
+	return strings.Join(items, ", ")
+}
+
+// Step is what a phase took.
+type Step struct {
+	Phase Phase `json:"phase"`
+	// IDs are the NUMA nodes, sockets, uncore caches or cores involved, depending on the phase.
+	IDs  cpuset.CPUSet `json:"ids"`
+	CPUs cpuset.CPUSet `json:"cpus"`
+}
+
+func (step Step) String() string {
+	return fmt.Sprintf("%s %s: %s", step.Phase, step.IDs.String(), step.CPUs.String())
+}
+
+// Explanation is how an exclusive CPU set came together.
+type Explanation struct {
+	Strategy Strategy `json:"strategy"`
+	// Steps are in the order the accumulator runs the phases; phases which took nothing are omitted.
+	Steps []Step `json:"steps"`
+}
+
+// Explain tells which phases took the allocated CPUs, given the CPUs which were free before.
+// The allocated CPUs not free before, like those reused from init containers, are not explained.
+func Explain(topo *topology.CPUTopology, st Strategy, freeBefore, allocated cpuset.CPUSet) Explanation {
+	ex := Explanation{Strategy: st}
+	details := topo.CPUDetails
+	remaining := allocated.Intersection(freeBefore)
+
+	takeWhole := func(phase Phase, ids cpuset.CPUSet, cpusOf func(id int) cpuset.CPUSet) {
+		var taken []int
+		cpus := cpuset.New()
+		for _, id := range ids.List() {
+			idCPUs := cpusOf(id)
+			if !idCPUs.IsSubsetOf(remaining) {
+				continue
+			}
+			taken = append(taken, id)
+			cpus = cpus.Union(idCPUs)
+		}
+		if len(taken) == 0 {
+			return
+		}
+		ex.Steps = append(ex.Steps, Step{Phase: phase, IDs: cpuset.New(taken...), CPUs: cpus})
+		remaining = remaining.Difference(cpus)
+	}
+	numaNodes := func() {
+		takeWhole(PhaseNUMANodes, details.NUMANodes(), func(id int) cpuset.CPUSet { return details.CPUsInNUMANodes(id) })
+	}
+	sockets := func() {
+		takeWhole(PhaseSockets, details.Sockets(), func(id int) cpuset.CPUSet { return details.CPUsInSockets(id) })
+	}
+
+	// mirrors the NUMA or sockets first accessors of the accumulator
+	if topo.NumSockets >= topo.NumNUMANodes {
+		numaNodes()
+		sockets()
+	} else {
+		sockets()
+		numaNodes()
+	}
+	if st.PreferUncore {
+		takeWhole(PhaseUncoreCaches, details.UncoreCaches(), func(id int) cpuset.CPUSet { return details.CPUsInUncoreCaches(id) })
+	}
+	if !st.SpreadCores {
+		takeWhole(PhaseCores, details.Cores(), func(id int) cpuset.CPUSet { return details.CPUsInCores(id) })
+	}
+
+	var usedCores, freeCores []int
+	usedCPUs, freeCPUs := cpuset.New(), cpuset.New()
+	for _, coreID := range details.KeepOnly(remaining).Cores().List() {
+		coreCPUs := details.CPUsInCores(coreID)
+		if coreCPUs.IsSubsetOf(freeBefore) {
+			freeCores = append(freeCores, coreID)
+			freeCPUs = freeCPUs.Union(coreCPUs.Intersection(remaining))
+		} else {
+			usedCores = append(usedCores, coreID)
+			usedCPUs = usedCPUs.Union(coreCPUs.Intersection(remaining))
+		}
+	}
+	if len(usedCores) > 0 {
+		ex.Steps = append(ex.Steps, Step{Phase: PhaseUsedCoreThreads, IDs: cpuset.New(usedCores...), CPUs: usedCPUs})
+	}
+	if len(freeCores) > 0 {
+		ex.Steps = append(ex.Steps, Step{Phase: PhaseFreeCoreThreads, IDs: cpuset.New(freeCores...), CPUs: freeCPUs})
+	}
+	return ex
+}
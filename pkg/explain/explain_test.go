@@ -0,0 +1,112 @@
+/*
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2026 Red Hat, Inc.
+ */
+
+package explain
+
+import (
+	"reflect"
+	"testing"
+
+	"k8s.io/utils/cpuset"
+
+	"github.com/ffromani/cpumgrx/internal/testutil"
+)
+
+func TestExplain(t *testing.T) {
+	topo := testutil.MakeTopology(2)
+	allFree := cpuset.New(1, 2, 3, 4, 5, 6, 7, 9, 10, 11, 12, 13, 14, 15)
+	tests := []struct {
+		name       string
+		strategy   Strategy
+		freeBefore cpuset.CPUSet
+		allocated  cpuset.CPUSet
+		expected   []Step
+	}{
+		{
+			name:       "whole numa node",
+			freeBefore: allFree,
+			allocated:  cpuset.New(4, 5, 6, 7, 12, 13, 14, 15),
+			expected: []Step{
+				{Phase: PhaseNUMANodes, IDs: cpuset.New(1), CPUs: cpuset.New(4, 5, 6, 7, 12, 13, 14, 15)},
+			},
+		},
+		{
+			name:       "whole cores and a leftover thread",
+			freeBefore: allFree,
+			allocated:  cpuset.New(1, 2, 9),
+			expected: []Step{
+				{Phase: PhaseCores, IDs: cpuset.New(1), CPUs: cpuset.New(1, 9)},
+				{Phase: PhaseFreeCoreThreads, IDs: cpuset.New(2), CPUs: cpuset.New(2)},
+			},
+		},
+		{
+			name:       "thread of a used core",
+			freeBefore: allFree.Difference(cpuset.New(1)),
+			allocated:  cpuset.New(9),
+			expected: []Step{
+				{Phase: PhaseUsedCoreThreads, IDs: cpuset.New(1), CPUs: cpuset.New(9)},
+			},
+		},
+		{
+			name:       "whole uncore cache",
+			strategy:   Strategy{PreferUncore: true},
+			freeBefore: allFree,
+			allocated:  cpuset.New(2, 3, 10, 11),
+			expected: []Step{
+				{Phase: PhaseUncoreCaches, IDs: cpuset.New(1), CPUs: cpuset.New(2, 3, 10, 11)},
+			},
+		},
+		{
+			name:       "spread across cores",
+			strategy:   Strategy{SpreadCores: true},
+			freeBefore: allFree,
+			allocated:  cpuset.New(2, 3, 10, 11),
+			expected: []Step{
+				{Phase: PhaseFreeCoreThreads, IDs: cpuset.New(2, 3), CPUs: cpuset.New(2, 3, 10, 11)},
+			},
+		},
+		{
+			name:       "reused cpus are not explained",
+			freeBefore: allFree.Difference(cpuset.New(1, 9)),
+			allocated:  cpuset.New(1, 9),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Explain(topo, tt.strategy, tt.freeBefore, tt.allocated)
+			if !reflect.DeepEqual(got.Steps, tt.expected) {
+				t.Errorf("got %v expected %v", got.Steps, tt.expected)
+			}
+		})
+	}
+}
+
+func TestStrategyFromOptions(t *testing.T) {
+	st, err := StrategyFromOptions(map[string]string{
+		"full-pcpus-only":              "true",
+		"distribute-cpus-across-cores": "true",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if expected := (Strategy{SpreadCores: true}); st != expected {
+		t.Errorf("got %+v expected %+v", st, expected)
+	}
+	if _, err := StrategyFromOptions(map[string]string{"distribute-cpus-across-numa": "maybe"}); err == nil {
+		t.Errorf("bad option value accepted")
+	}
+}
@@ -0,0 +1,261 @@
+/*
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2026 Red Hat, Inc.
+ */
+
+// Package klogcapture collects what the kubelet managers log while handling a step,
+// so it can be shown next to the outcome of the step instead of on stderr.
+package klogcapture
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-logr/logr"
+	"k8s.io/klog/v2"
+)
+
+// DefaultVerbosity is enough to get the decisions of the cpu manager static policy.
+const DefaultVerbosity = 5
+
+// Value is a key/value pair of a structured log line, formatted.
+type Value struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+// Entry is a log line captured during a step.
+type Entry struct {
+	// Source is the file:line which logged the entry
+	Source  string  `json:"source"`
+	Message string  `json:"message"`
+	Values  []Value `json:"values,omitempty"`
+	Error   string  `json:"error,omitempty"`
+	// Container is the container the step was handling when the entry was logged, if known.
+	Container string `json:"container,omitempty"`
+}
+
+func (e Entry) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s] %q", e.Source, e.Message)
+	if e.Error != "" {
+		fmt.Fprintf(&b, " err=%q", e.Error)
+	}
+	for _, val := range e.Values {
+		fmt.Fprintf(&b, " %s=%s", val.Key, val.Value)
+	}
+	return b.String()
+}
+
+// Capture routes the klog output to itself. Entries logged within a step are recorded,
+// all the others are printed on stderr like klog would do.
+type Capture struct {
+	// the klog verbosity within steps, unless the user asked for more
+	verbosity     int
+	userVerbosity string
+	// the klog flags, to change the verbosity around steps
+	flags *flag.FlagSet
+	// serializes the steps, so the entries of concurrent steps don't mix
+	step sync.Mutex
+	lock sync.Mutex
+	// set within steps
+	recording bool
+	container string
+	entries   []Entry
+	stderr    io.Writer
+}
+
+var active atomic.Pointer[Capture]
+
+// Active returns the capture which was started, or nil.
+func Active() *Capture {
+	return active.Load()
+}
+
+// Start routes the klog output through a new capture. Within steps the klog verbosity
+// is raised to the given level, so the managers log their decisions.
+func Start(verbosity int) *Capture {
+	c := &Capture{
+		verbosity: verbosity,
+		flags:     flag.NewFlagSet("klog", flag.ContinueOnError),
+		stderr:    os.Stderr,
+	}
+	// klog binds the flags of any flagset to its global settings
+	klog.InitFlags(c.flags)
+	klog.SetLogger(logr.New(&sink{capture: c}))
+	active.Store(c)
+	return c
+}
+
+// Stop gives the klog output back to klog.
+func (c *Capture) Stop() {
+	active.CompareAndSwap(c, nil)
+	klog.ClearLogger()
+}
+
+// Begin starts recording a step. Steps are serialized: Begin blocks until the
+// step in progress, if any, ends.
+func (c *Capture) Begin() {
+	c.step.Lock()
+	c.userVerbosity = c.flags.Lookup("v").Value.String()
+	if userLevel, err := strconv.Atoi(c.userVerbosity); err != nil || userLevel < c.verbosity {
+		c.setVerbosity(strconv.Itoa(c.verbosity))
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.recording = true
+	c.container = ""
+	c.entries = nil
+}
+
+// End stops recording the step and returns the entries recorded in it.
+func (c *Capture) End() []Entry {
+	defer c.step.Unlock()
+
+	c.lock.Lock()
+	entries := c.entries
+	c.recording = false
+	c.container = ""
+	c.entries = nil
+	c.lock.Unlock()
+
+	c.setVerbosity(c.userVerbosity)
+	return entries
+}
+
+func (c *Capture) setVerbosity(verbosity string) {
+	if err := c.flags.Set("v", verbosity); err != nil {
+		c.write("E", Entry{Message: "cannot set the klog verbosity", Error: err.Error()})
+	}
+}
+
+func (c *Capture) record(severity string, entry Entry) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	// errors show up on stderr anyway
+	if !c.recording || severity == "E" {
+		c.write(severity, entry)
+	}
+	if !c.recording {
+		return
+	}
+	for _, val := range entry.Values {
+		if val.Key == "containerName" || val.Key == "container" {
+			c.container, _ = strconv.Unquote(val.Value)
+		}
+	}
+	entry.Container = c.container
+	c.entries = append(c.entries, entry)
+}
+
+func (c *Capture) write(severity string, entry Entry) {
+	fmt.Fprintf(c.stderr, "%s%s %s\n", severity, time.Now().Format("0102 15:04:05.000000"), entry.String())
+}
+
+// sink is the logr side of a capture.
+type sink struct {
+	capture *Capture
+	depth   int
+	values  []any
+}
+
+var _ logr.CallDepthLogSink = &sink{}
+
+func (s *sink) Init(info logr.RuntimeInfo) {
+	s.depth = info.CallDepth
+}
+
+func (s *sink) Enabled(level int) bool {
+	// klog checks the verbosity itself
+	return true
+}
+
+func (s *sink) Info(level int, msg string, keysAndValues ...any) {
+	s.capture.record("I", s.makeEntry(msg, keysAndValues))
+}
+
+func (s *sink) Error(err error, msg string, keysAndValues ...any) {
+	entry := s.makeEntry(msg, keysAndValues)
+	if err != nil {
+		entry.Error = err.Error()
+	}
+	s.capture.record("E", entry)
+}
+
+func (s *sink) WithValues(keysAndValues ...any) logr.LogSink {
+	ret := *s
+	ret.values = append(append([]any{}, s.values...), keysAndValues...)
+	return &ret
+}
+
+func (s *sink) WithName(name string) logr.LogSink {
+	return s
+}
+
+func (s *sink) WithCallDepth(depth int) logr.LogSink {
+	ret := *s
+	ret.depth += depth
+	return &ret
+}
+
+// makeEntry must be called by the logr entry points only, to find the source.
+func (s *sink) makeEntry(msg string, keysAndValues []any) Entry {
+	entry := Entry{
+		Source:  "???:1",
+		Message: msg,
+		Values:  formatValues(append(append([]any{}, s.values...), keysAndValues...)),
+	}
+	// skip ourselves and the sink method
+	if _, file, line, ok := runtime.Caller(s.depth + 2); ok {
+		entry.Source = filepath.Base(file) + ":" + strconv.Itoa(line)
+	}
+	return entry
+}
+
+func formatValues(keysAndValues []any) []Value {
+	var values []Value
+	for idx := 0; idx < len(keysAndValues); idx += 2 {
+		key := fmt.Sprint(keysAndValues[idx])
+		val := "(MISSING)"
+		if idx+1 < len(keysAndValues) {
+			val = formatValue(keysAndValues[idx+1])
+		}
+		values = append(values, Value{Key: key, Value: val})
+	}
+	return values
+}
+
+// formatValue mimics the klog text format.
+func formatValue(v any) string {
+	switch val := v.(type) {
+	case string:
+		return strconv.Quote(val)
+	case error:
+		return strconv.Quote(val.Error())
+	case fmt.Stringer:
+		return strconv.Quote(val.String())
+	default:
+		return fmt.Sprintf("%+v", val)
+	}
+}
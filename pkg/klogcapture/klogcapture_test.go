@@ -0,0 +1,85 @@
+/*
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2026 Red Hat, Inc.
+ */
+
+package klogcapture
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"k8s.io/klog/v2"
+	"k8s.io/utils/cpuset"
+)
+
+func TestCapture(t *testing.T) {
+	capture := Start(DefaultVerbosity)
+	defer capture.Stop()
+	var stderr strings.Builder
+	capture.stderr = &stderr
+
+	klog.Infof("before the step")
+	klog.V(4).InfoS("too verbose outside the step")
+
+	capture.Begin()
+	klog.V(4).InfoS("pod level")
+	klog.V(4).InfoS("allocating", "containerName", "cnt", "cpuSet", cpuset.New(1, 2))
+	klog.V(5).Infof("container level")
+	klog.ErrorS(errors.New("boom"), "failed", "numCPUs", 3)
+	entries := capture.End()
+
+	klog.V(4).InfoS("too verbose after the step")
+
+	if len(entries) != 4 {
+		t.Fatalf("unexpected entries: %v", entries)
+	}
+	expected := []struct {
+		message   string
+		container string
+	}{
+		{"pod level", ""},
+		{"allocating", "cnt"},
+		{"container level", "cnt"},
+		{"failed", "cnt"},
+	}
+	for idx, exp := range expected {
+		entry := entries[idx]
+		if entry.Message != exp.message || entry.Container != exp.container {
+			t.Errorf("entry %d: got %q container %q expected %q container %q", idx, entry.Message, entry.Container, exp.message, exp.container)
+		}
+		if !strings.HasPrefix(entry.Source, "klogcapture_test.go:") {
+			t.Errorf("entry %d: unexpected source %q", idx, entry.Source)
+		}
+	}
+	if got := entries[1].String(); !strings.HasSuffix(got, `] "allocating" containerName="cnt" cpuSet="1-2"`) {
+		t.Errorf("unexpected entry format: %s", got)
+	}
+	if got := entries[3].String(); !strings.HasSuffix(got, `] "failed" err="boom" numCPUs=3`) {
+		t.Errorf("unexpected entry format: %s", got)
+	}
+
+	out := stderr.String()
+	for _, msg := range []string{"before the step", "failed"} {
+		if !strings.Contains(out, msg) {
+			t.Errorf("missing %q on stderr: %s", msg, out)
+		}
+	}
+	for _, msg := range []string{"too verbose", "container level"} {
+		if strings.Contains(out, msg) {
+			t.Errorf("unexpected %q on stderr: %s", msg, out)
+		}
+	}
+}
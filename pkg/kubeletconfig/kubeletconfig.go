@@ -0,0 +1,119 @@
+/*
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2026 Red Hat, Inc.
+ */
+
+// Package kubeletconfig reads the settings relevant to the CPU manager from
+// a KubeletConfiguration file. We only carry the fields we care about, and
+// ignore everything else.
+package kubeletconfig
+
+import (
+	"fmt"
+	"os"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/utils/cpuset"
+	"sigs.k8s.io/yaml"
+
+	"github.com/ffromani/cpumgrx/pkg/cpumgrx"
+)
+
+const (
+	Kind = "KubeletConfiguration"
+
+	// kubelet defaults
+	DefaultCPUManagerPolicy      = "none"
+	DefaultTopologyManagerPolicy = "none"
+	DefaultTopologyManagerScope  = "container"
+)
+
+// KubeletConfiguration mirrors the subset of kubelet.config.k8s.io/v1beta1 KubeletConfiguration we need.
+type KubeletConfiguration struct {
+	Kind                         string            `json:"kind,omitempty"`
+	APIVersion                   string            `json:"apiVersion,omitempty"`
+	CPUManagerPolicy             string            `json:"cpuManagerPolicy,omitempty"`
+	CPUManagerPolicyOptions      map[string]string `json:"cpuManagerPolicyOptions,omitempty"`
+	ReservedSystemCPUs           string            `json:"reservedSystemCPUs,omitempty"`
+	KubeReserved                 map[string]string `json:"kubeReserved,omitempty"`
+	SystemReserved               map[string]string `json:"systemReserved,omitempty"`
+	TopologyManagerPolicy        string            `json:"topologyManagerPolicy,omitempty"`
+	TopologyManagerScope         string            `json:"topologyManagerScope,omitempty"`
+	TopologyManagerPolicyOptions map[string]string `json:"topologyManagerPolicyOptions,omitempty"`
+	FeatureGates                 map[string]bool   `json:"featureGates,omitempty"`
+}
+
+func Read(path string) (*KubeletConfiguration, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(data)
+}
+
+func Parse(data []byte) (*KubeletConfiguration, error) {
+	var kc KubeletConfiguration
+	if err := yaml.Unmarshal(data, &kc); err != nil {
+		return nil, err
+	}
+	if kc.Kind != "" && kc.Kind != Kind {
+		return nil, fmt.Errorf("unexpected kind %q, expected %q", kc.Kind, Kind)
+	}
+	return &kc, nil
+}
+
+// Apply sets on the params the values found in the configuration, using
+// the kubelet defaults for the missing ones.
+func (kc *KubeletConfiguration) Apply(params *cpumgrx.Params) error {
+	params.PolicyName = valueOr(kc.CPUManagerPolicy, DefaultCPUManagerPolicy)
+	params.CPUPolicyOptions = kc.CPUManagerPolicyOptions
+	params.TMPolicyName = valueOr(kc.TopologyManagerPolicy, DefaultTopologyManagerPolicy)
+	params.TMScopeName = valueOr(kc.TopologyManagerScope, DefaultTopologyManagerScope)
+	params.TMPolicyOptions = kc.TopologyManagerPolicyOptions
+	params.FeatureGates = kc.FeatureGates
+
+	reservedCPUs, err := cpuset.Parse(kc.ReservedSystemCPUs)
+	if err != nil {
+		return fmt.Errorf("bad reservedSystemCPUs: %w", err)
+	}
+	params.ReservedCPUSet = reservedCPUs
+	if reservedCPUs.Size() > 0 {
+		// like the kubelet does, the explicit set takes precedence over kube and system reserved cpu
+		params.ReservedCPUQty = *resource.NewQuantity(int64(reservedCPUs.Size()), resource.DecimalSI)
+		return nil
+	}
+
+	qty := resource.Quantity{}
+	for _, reserved := range []map[string]string{kc.KubeReserved, kc.SystemReserved} {
+		rawQty, ok := reserved[string(v1.ResourceCPU)]
+		if !ok {
+			continue
+		}
+		cpuQty, err := resource.ParseQuantity(rawQty)
+		if err != nil {
+			return fmt.Errorf("bad reserved cpu %q: %w", rawQty, err)
+		}
+		qty.Add(cpuQty)
+	}
+	params.ReservedCPUQty = qty
+	return nil
+}
+
+func valueOr(value, fallback string) string {
+	if value == "" {
+		return fallback
+	}
+	return value
+}
@@ -0,0 +1,117 @@
+/*
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2026 Red Hat, Inc.
+ */
+
+package kubeletconfig
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/utils/cpuset"
+
+	"github.com/ffromani/cpumgrx/pkg/cpumgrx"
+)
+
+func TestApply(t *testing.T) {
+	testCases := []struct {
+		name             string
+		data             string
+		expectedError    bool
+		expectedPolicy   string
+		expectedTMPolicy string
+		expectedReserved cpuset.CPUSet
+		expectedQty      string
+	}{
+		{
+			name: "defaults",
+			data: `apiVersion: kubelet.config.k8s.io/v1beta1
+kind: KubeletConfiguration
+systemReserved:
+  cpu: "1"
+`,
+			expectedPolicy:   "none",
+			expectedTMPolicy: "none",
+			expectedReserved: cpuset.New(),
+			expectedQty:      "1",
+		},
+		{
+			name: "reserved cpus take precedence",
+			data: `kind: KubeletConfiguration
+cpuManagerPolicy: static
+topologyManagerPolicy: single-numa-node
+reservedSystemCPUs: "0,52"
+kubeReserved:
+  cpu: "4"
+`,
+			expectedPolicy:   "static",
+			expectedTMPolicy: "single-numa-node",
+			expectedReserved: cpuset.New(0, 52),
+			expectedQty:      "2",
+		},
+		{
+			name: "kube and system reserved add up",
+			data: `kind: KubeletConfiguration
+cpuManagerPolicy: static
+kubeReserved:
+  cpu: 500m
+systemReserved:
+  cpu: 1500m
+`,
+			expectedPolicy:   "static",
+			expectedTMPolicy: "none",
+			expectedReserved: cpuset.New(),
+			expectedQty:      "2",
+		},
+		{
+			name:          "wrong kind",
+			data:          "kind: Pod\n",
+			expectedError: true,
+		},
+		{
+			name:          "bad reserved cpus",
+			data:          "reservedSystemCPUs: foo\n",
+			expectedError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var params cpumgrx.Params
+			kc, err := Parse([]byte(tc.data))
+			if err == nil {
+				err = kc.Apply(&params)
+			}
+			if (err != nil) != tc.expectedError {
+				t.Fatalf("got error %v expected error %v", err, tc.expectedError)
+			}
+			if tc.expectedError {
+				return
+			}
+			if params.PolicyName != tc.expectedPolicy {
+				t.Errorf("policy got %q expected %q", params.PolicyName, tc.expectedPolicy)
+			}
+			if params.TMPolicyName != tc.expectedTMPolicy {
+				t.Errorf("TM policy got %q expected %q", params.TMPolicyName, tc.expectedTMPolicy)
+			}
+			if !params.ReservedCPUSet.Equals(tc.expectedReserved) {
+				t.Errorf("reserved got %v expected %v", params.ReservedCPUSet, tc.expectedReserved)
+			}
+			if params.ReservedCPUQty.Cmp(resource.MustParse(tc.expectedQty)) != 0 {
+				t.Errorf("reserved quantity got %v expected %v", params.ReservedCPUQty.String(), tc.expectedQty)
+			}
+		})
+	}
+}
@@ -0,0 +1,442 @@
+/*
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2026 Red Hat, Inc.
+ */
+
+// Package oracle searches for a well aligned allocation of a sequence of pods,
+// to estimate how far the greedy static policy is from the optimum.
+//
+// Each container getting exclusive CPUs is allocated on its own, like the
+// static policy does. The init containers which are not sidecars are left
+// out, since their CPUs go to the containers after them. The search honors
+// the topology manager policy and scope: with restricted and single-numa-node
+// a container, or the whole pod with the pod scope, must fit the fewest NUMA
+// nodes possible, or one, else the pod is rejected.
+//
+// For each container, the search explores the inclusion-minimal sets of NUMA
+// nodes and, within them, the inclusion-minimal sets of uncore caches which
+// can fit the request. CPUs within an uncore cache are interchangeable for
+// alignment purposes, so full cores are taken first, and leftover threads
+// are taken from cores already split, if any.
+//
+// The search is a heuristic, not an exhaustive one: sets larger than the
+// smallest fitting one plus one are skipped, and the CPUs are packed in
+// the set always the same way. The best allocation is the best one the
+// search found, so its penalty is an upper bound of the true optimum.
+package oracle
+
+import (
+	"sort"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/kubernetes/pkg/kubelet/cm/cpumanager/topology"
+	"k8s.io/utils/cpuset"
+
+	"github.com/ffromani/cpumgrx/internal/combinations"
+	"github.com/ffromani/cpumgrx/pkg/alignment"
+	"github.com/ffromani/cpumgrx/pkg/cpumgrx"
+)
+
+const (
+	DefaultMaxSteps = 1000000
+
+	// PenaltyRejected is the penalty of a pod which cannot be allocated at all.
+	PenaltyRejected = 1000000
+)
+
+type Params struct {
+	Manager cpumgrx.Params
+	// MaxSteps bounds the search. If exceeded, the best allocation found so far is reported,
+	// or the greedy one if the search found none.
+	MaxSteps int
+}
+
+// Allocation is what a pod got. The penalty is the sum of the penalties of its containers.
+type Allocation struct {
+	CPUs     cpuset.CPUSet  `json:"cpus"`
+	Info     alignment.Info `json:"info"`
+	Rejected bool           `json:"rejected,omitempty"`
+	Penalty  int            `json:"penalty"`
+}
+
+type PodResult struct {
+	Name    string     `json:"name"`
+	Request int        `json:"request"`
+	Greedy  Allocation `json:"greedy"`
+	Best    Allocation `json:"best"`
+}
+
+// Gap is how much worse the greedy allocation is compared to the best one found.
+// Can be negative: the search minimizes the total penalty, so it may trade
+// the alignment of a pod for the alignment of others.
+func (pr PodResult) Gap() int {
+	return pr.Greedy.Penalty - pr.Best.Penalty
+}
+
+type Report struct {
+	// Truncated is true if the search was cut short by MaxSteps.
+	Truncated     bool        `json:"truncated"`
+	Steps         int         `json:"steps"`
+	GreedyPenalty int         `json:"greedyPenalty"`
+	BestPenalty   int         `json:"bestPenalty"`
+	Pods          []PodResult `json:"pods"`
+}
+
+func Run(params Params, pods []*v1.Pod) (Report, error) {
+	topo, err := topology.Discover(params.Manager.MachineInfo)
+	if err != nil {
+		return Report{}, err
+	}
+
+	greedy, err := runGreedy(params.Manager, topo, pods)
+	if err != nil {
+		return Report{}, err
+	}
+
+	maxSteps := params.MaxSteps
+	if maxSteps <= 0 {
+		maxSteps = DefaultMaxSteps
+	}
+	sr := searcher{
+		topo:      topo,
+		tmPolicy:  params.Manager.TMPolicyName,
+		podScope:  params.Manager.TMScopeName == "pod",
+		maxSteps:  maxSteps,
+		bestTotal: -1,
+	}
+	for _, pod := range pods {
+		var requests []int
+		for _, cnt := range exclusiveContainers(pod) {
+			requests = append(requests, cpumgrx.GuaranteedCPUs(pod, &cnt))
+		}
+		sr.requests = append(sr.requests, requests)
+	}
+	free := topo.CPUDetails.CPUs().Difference(params.Manager.ReservedCPUSet)
+	sr.search(0, free, make([]Allocation, len(pods)), 0)
+	if sr.best == nil {
+		// cut short before any complete allocation: the greedy one is the best we know
+		sr.best = greedy
+	}
+
+	rp := Report{
+		Truncated: sr.truncated,
+		Steps:     sr.steps,
+	}
+	for idx, pod := range pods {
+		pr := PodResult{
+			Name:    pod.Name,
+			Request: sum(sr.requests[idx]),
+			Greedy:  greedy[idx],
+			Best:    sr.best[idx],
+		}
+		rp.GreedyPenalty += pr.Greedy.Penalty
+		rp.BestPenalty += pr.Best.Penalty
+		rp.Pods = append(rp.Pods, pr)
+	}
+	return rp, nil
+}
+
+func runGreedy(params cpumgrx.Params, topo *topology.CPUTopology, pods []*v1.Pod) ([]Allocation, error) {
+	params.StateFileDirectory = "" // start from a clean state
+	mgrx, err := cpumgrx.NewFromParams(params)
+	if err != nil {
+		return nil, err
+	}
+	defer mgrx.Close()
+
+	var res []Allocation
+	for _, pod := range pods {
+		if _, err := mgrx.Run(pod); err != nil {
+			res = append(res, Allocation{Rejected: true, Penalty: PenaltyRejected})
+			continue
+		}
+		cpus := mgrx.GetPodExclusiveCPUs(pod)
+		alloc := Allocation{
+			CPUs: cpus,
+			Info: alignment.Describe(topo, cpus),
+		}
+		for _, cnt := range exclusiveContainers(pod) {
+			alloc.Penalty += makeAllocation(topo, mgrx.GetContainerExclusiveCPUs(pod, cnt.Name)).Penalty
+		}
+		res = append(res, alloc)
+	}
+	return res, nil
+}
+
+// exclusiveContainers returns the containers of the pod which keep exclusive CPUs:
+// the sidecars and the app containers, in the order they are allocated.
+func exclusiveContainers(pod *v1.Pod) []v1.Container {
+	var res []v1.Container
+	for _, cnt := range pod.Spec.InitContainers {
+		if cnt.RestartPolicy != nil && *cnt.RestartPolicy == v1.ContainerRestartPolicyAlways && cpumgrx.GuaranteedCPUs(pod, &cnt) > 0 {
+			res = append(res, cnt)
+		}
+	}
+	for _, cnt := range pod.Spec.Containers {
+		if cpumgrx.GuaranteedCPUs(pod, &cnt) > 0 {
+			res = append(res, cnt)
+		}
+	}
+	return res
+}
+
+func makeAllocation(topo *topology.CPUTopology, cpus cpuset.CPUSet) Allocation {
+	info := alignment.Describe(topo, cpus)
+	return Allocation{
+		CPUs:    cpus,
+		Info:    info,
+		Penalty: alignment.Penalty(info, alignment.Best(topo, cpus.Size())),
+	}
+}
+
+type searcher struct {
+	topo *topology.CPUTopology
+	// requests are the exclusive CPUs of each container of each pod
+	requests  [][]int
+	tmPolicy  string
+	podScope  bool
+	maxSteps  int
+	steps     int
+	truncated bool
+	best      []Allocation
+	bestTotal int
+}
+
+// step accounts for a search step, and tells if the search can go on.
+func (sr *searcher) step() bool {
+	if sr.steps >= sr.maxSteps {
+		sr.truncated = true
+		return false
+	}
+	sr.steps++
+	return true
+}
+
+func (sr *searcher) search(idx int, free cpuset.CPUSet, cur []Allocation, total int) {
+	if !sr.step() {
+		return
+	}
+	if sr.bestTotal >= 0 && total >= sr.bestTotal {
+		return // can't get any better than what we have already
+	}
+	if idx == len(sr.requests) {
+		sr.bestTotal = total
+		sr.best = append([]Allocation{}, cur...)
+		return
+	}
+
+	if len(sr.requests[idx]) == 0 {
+		// shared pool, nothing to allocate
+		cur[idx] = Allocation{}
+		sr.search(idx+1, free, cur, total)
+		return
+	}
+
+	placed := false
+	for _, allowed := range sr.podCPUs(free, sum(sr.requests[idx])) {
+		placed = sr.place(idx, 0, free, allowed, cpuset.New(), cur, total, 0) || placed
+	}
+	if !placed {
+		// like the kubelet, nothing is left allocated to a rejected pod
+		cur[idx] = Allocation{Rejected: true, Penalty: PenaltyRejected}
+		sr.search(idx+1, free, cur, total+PenaltyRejected)
+	}
+}
+
+// place allocates the containers of the pod from the cntIdx-th on, out of the allowed
+// CPUs, then goes on with the next pod. Tells if all the containers could be placed.
+func (sr *searcher) place(idx, cntIdx int, free, allowed, cpus cpuset.CPUSet, cur []Allocation, total, penalty int) bool {
+	if sr.bestTotal >= 0 && total+penalty >= sr.bestTotal {
+		return false // pruned: the rejection gets pruned as well
+	}
+	requests := sr.requests[idx]
+	if cntIdx == len(requests) {
+		cur[idx] = Allocation{
+			CPUs:    cpus,
+			Info:    alignment.Describe(sr.topo, cpus),
+			Penalty: penalty,
+		}
+		sr.search(idx+1, free, cur, total+penalty)
+		return true
+	}
+	placed := false
+	for _, cand := range sr.candidates(free.Intersection(allowed), requests[cntIdx], !sr.podScope) {
+		if !sr.step() {
+			return placed
+		}
+		placed = sr.place(idx, cntIdx+1, free.Difference(cand.CPUs), allowed, cpus.Union(cand.CPUs), cur, total, penalty+cand.Penalty) || placed
+	}
+	return placed
+}
+
+// maxNUMANodes returns how many NUMA nodes the topology manager policy lets the given
+// amount of CPUs span, or 0 if there is no limit.
+func (sr *searcher) maxNUMANodes(numCPUs int) int {
+	switch sr.tmPolicy {
+	case "single-numa-node":
+		return 1
+	case "restricted":
+		return alignment.Best(sr.topo, numCPUs).NUMANodes
+	}
+	return 0
+}
+
+// podCPUs returns the sets of CPUs the containers of the pod can be allocated from.
+// With the pod scope, the whole pod must fit the NUMA nodes the policy allows.
+func (sr *searcher) podCPUs(free cpuset.CPUSet, numCPUs int) []cpuset.CPUSet {
+	maxNUMANodes := sr.maxNUMANodes(numCPUs)
+	if !sr.podScope || maxNUMANodes == 0 {
+		return []cpuset.CPUSet{sr.topo.CPUDetails.CPUs()}
+	}
+	var res []cpuset.CPUSet
+	numaIDs := sr.topo.CPUDetails.NUMANodes().List()
+	combinations.Visit(len(numaIDs), min(maxNUMANodes, len(numaIDs)), func(idxs []int) bool {
+		ids := make([]int, 0, len(idxs))
+		for _, idx := range idxs {
+			ids = append(ids, numaIDs[idx])
+		}
+		if cpus := sr.topo.CPUDetails.CPUsInNUMANodes(ids...); cpus.Intersection(free).Size() >= numCPUs {
+			res = append(res, cpus)
+		}
+		return true
+	})
+	return res
+}
+
+func sum(xs []int) int {
+	res := 0
+	for _, x := range xs {
+		res += x
+	}
+	return res
+}
+
+// candidates returns all the distinct allocations worth exploring, best first.
+// If limitNUMA is set, the allocations span no more NUMA nodes than the policy allows.
+func (sr *searcher) candidates(free cpuset.CPUSet, numCPUs int, limitNUMA bool) []Allocation {
+	if free.Size() < numCPUs {
+		return nil
+	}
+
+	maxNUMANodes := 0
+	if limitNUMA {
+		maxNUMANodes = sr.maxNUMANodes(numCPUs)
+	}
+	var res []Allocation
+	seen := make(map[string]bool)
+	numaIDs := sr.topo.CPUDetails.NUMANodes().List()
+	for _, numaSet := range minimalSubsets(numaIDs, func(ids []int) bool {
+		return sr.topo.CPUDetails.CPUsInNUMANodes(ids...).Intersection(free).Size() >= numCPUs
+	}) {
+		if maxNUMANodes > 0 && len(numaSet) > maxNUMANodes {
+			continue
+		}
+		uncoreIDs := sr.topo.CPUDetails.UncoreInNUMANodes(numaSet...).List()
+		for _, uncoreSet := range minimalSubsets(uncoreIDs, func(ids []int) bool {
+			return sr.topo.CPUDetails.CPUsInUncoreCaches(ids...).Intersection(free).Size() >= numCPUs
+		}) {
+			cpus, ok := sr.take(free, numCPUs, uncoreSet)
+			if !ok || seen[cpus.String()] {
+				continue
+			}
+			seen[cpus.String()] = true
+			res = append(res, makeAllocation(sr.topo, cpus))
+		}
+	}
+
+	sort.SliceStable(res, func(i, j int) bool {
+		return res[i].Penalty < res[j].Penalty
+	})
+	return res
+}
+
+// take picks numCPUs from the given uncore caches, packing them: the fullest
+// uncore caches first, full cores first, leftover threads from split cores first.
+func (sr *searcher) take(free cpuset.CPUSet, numCPUs int, uncoreIDs []int) (cpuset.CPUSet, bool) {
+	sort.SliceStable(uncoreIDs, func(i, j int) bool {
+		freeI := sr.topo.CPUDetails.CPUsInUncoreCaches(uncoreIDs[i]).Intersection(free).Size()
+		freeJ := sr.topo.CPUDetails.CPUsInUncoreCaches(uncoreIDs[j]).Intersection(free).Size()
+		return freeI < freeJ
+	})
+
+	var fullCores, splitCores []cpuset.CPUSet
+	for _, uncoreID := range uncoreIDs {
+		avail := sr.topo.CPUDetails.CPUsInUncoreCaches(uncoreID).Intersection(free)
+		for _, coreID := range sr.topo.CPUDetails.KeepOnly(avail).Cores().List() {
+			coreCPUs := sr.topo.CPUDetails.CPUsInCores(coreID)
+			if coreCPUs.IsSubsetOf(avail) {
+				fullCores = append(fullCores, coreCPUs)
+			} else {
+				splitCores = append(splitCores, coreCPUs.Intersection(avail))
+			}
+		}
+	}
+
+	res := cpuset.New()
+	for _, cpus := range fullCores {
+		if numCPUs-res.Size() < cpus.Size() {
+			break
+		}
+		res = res.Union(cpus)
+	}
+	for _, cpus := range append(splitCores, fullCores...) {
+		for _, cpuID := range cpus.Difference(res).List() {
+			if res.Size() == numCPUs {
+				return res, true
+			}
+			res = res.Union(cpuset.New(cpuID))
+		}
+	}
+	return res, res.Size() == numCPUs
+}
+
+// minimalSubsets returns the subsets of ids which satisfy the given
+// predicate, and which have no proper subset satisfying it. To keep the
+// search bounded, subsets larger than the smallest fitting one plus one
+// are not considered.
+func minimalSubsets(ids []int, fits func(ids []int) bool) [][]int {
+	var res [][]int
+	var found [][]int
+	minSize := -1
+	for size := 1; size <= len(ids); size++ {
+		if minSize > 0 && size > minSize+1 {
+			break
+		}
+		combinations.Visit(len(ids), size, func(idxs []int) bool {
+			subset := make([]int, 0, len(idxs))
+			for _, idx := range idxs {
+				subset = append(subset, ids[idx])
+			}
+			for _, fs := range found {
+				if isSubset(fs, subset) {
+					return true
+				}
+			}
+			if !fits(subset) {
+				return true
+			}
+			if minSize < 0 {
+				minSize = size
+			}
+			found = append(found, subset)
+			res = append(res, subset)
+			return true
+		})
+	}
+	return res
+}
+
+func isSubset(xs, ys []int) bool {
+	return cpuset.New(xs...).IsSubsetOf(cpuset.New(ys...))
+}
@@ -0,0 +1,189 @@
+/*
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2026 Red Hat, Inc.
+ */
+
+package oracle
+
+import (
+	"reflect"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/utils/cpuset"
+
+	"github.com/ffromani/cpumgrx/internal/testutil"
+	"github.com/ffromani/cpumgrx/pkg/cpumgrx"
+)
+
+func TestRun(t *testing.T) {
+	testCases := []struct {
+		name              string
+		tmPolicy          string
+		tmScope           string
+		pods              []*v1.Pod
+		maxSteps          int
+		expectedTruncated bool
+		expectedGreedy    int
+		expectedBest      int
+	}{
+		{
+			// the greedy policy puts the first pod on the NUMA node the second one would
+			// fit in, so the second one spans both; the best gives each a NUMA node.
+			name:           "best beats greedy",
+			pods:           []*v1.Pod{testutil.MakePod("a", "30"), testutil.MakePod("b", "40")},
+			expectedGreedy: 10001,
+			expectedBest:   0,
+		},
+		{
+			name:              "step limit",
+			pods:              []*v1.Pod{testutil.MakePod("a", "30"), testutil.MakePod("b", "40")},
+			maxSteps:          1,
+			expectedTruncated: true,
+			// no complete allocation found, so the greedy one is reported
+			expectedGreedy: 10001,
+			expectedBest:   10001,
+		},
+		{
+			name:           "second container spans",
+			pods:           []*v1.Pod{testutil.MakePod("a", "30"), testutil.MakePod("b", "500m", "40")},
+			expectedGreedy: 10001,
+			expectedBest:   0,
+		},
+		{
+			name:           "too big for a NUMA node",
+			tmPolicy:       "single-numa-node",
+			pods:           []*v1.Pod{testutil.MakePod("a", "60")},
+			expectedGreedy: PenaltyRejected,
+			expectedBest:   PenaltyRejected,
+		},
+		{
+			name:           "containers on different NUMA nodes",
+			tmPolicy:       "single-numa-node",
+			pods:           []*v1.Pod{testutil.MakePod("a", "30", "30")},
+			expectedGreedy: 0,
+			expectedBest:   0,
+		},
+		{
+			name:           "pod too big for a NUMA node",
+			tmPolicy:       "single-numa-node",
+			tmScope:        "pod",
+			pods:           []*v1.Pod{testutil.MakePod("a", "30", "30")},
+			expectedGreedy: PenaltyRejected,
+			expectedBest:   PenaltyRejected,
+		},
+		{
+			name:           "fewest NUMA nodes",
+			tmPolicy:       "restricted",
+			pods:           []*v1.Pod{testutil.MakePod("a", "30"), testutil.MakePod("b", "40")},
+			expectedGreedy: 0,
+			expectedBest:   0,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			params := testManagerParams(t)
+			if tc.tmPolicy != "" {
+				params.TMPolicyName = tc.tmPolicy
+			}
+			params.TMScopeName = tc.tmScope
+			rp, err := Run(Params{
+				Manager:  params,
+				MaxSteps: tc.maxSteps,
+			}, tc.pods)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if rp.Truncated != tc.expectedTruncated {
+				t.Errorf("truncated got %v expected %v", rp.Truncated, tc.expectedTruncated)
+			}
+			if rp.GreedyPenalty != tc.expectedGreedy || rp.BestPenalty != tc.expectedBest {
+				t.Errorf("penalties got greedy=%d best=%d expected greedy=%d best=%d", rp.GreedyPenalty, rp.BestPenalty, tc.expectedGreedy, tc.expectedBest)
+			}
+			if len(rp.Pods) != len(tc.pods) {
+				t.Fatalf("got %d pod results expected %d", len(rp.Pods), len(tc.pods))
+			}
+			allocated := cpuset.New()
+			for _, pr := range rp.Pods {
+				if pr.Best.Rejected {
+					continue
+				}
+				if pr.Best.CPUs.Size() != pr.Request {
+					t.Errorf("pod %q: got %d CPUs expected %d", pr.Name, pr.Best.CPUs.Size(), pr.Request)
+				}
+				if !allocated.Intersection(pr.Best.CPUs).IsEmpty() {
+					t.Errorf("pod %q: CPUs %v already allocated", pr.Name, pr.Best.CPUs)
+				}
+				allocated = allocated.Union(pr.Best.CPUs)
+			}
+		})
+	}
+}
+
+func TestMinimalSubsets(t *testing.T) {
+	weights := map[int]int{0: 4, 1: 2, 2: 2, 3: 1}
+	atLeast := func(amount int) func(ids []int) bool {
+		return func(ids []int) bool {
+			sum := 0
+			for _, id := range ids {
+				sum += weights[id]
+			}
+			return sum >= amount
+		}
+	}
+
+	tests := []struct {
+		name     string
+		amount   int
+		expected [][]int
+	}{
+		{
+			name:     "single item fits",
+			amount:   2,
+			expected: [][]int{{0}, {1}, {2}},
+		},
+		{
+			name:     "supersets are skipped",
+			amount:   4,
+			expected: [][]int{{0}, {1, 2}},
+		},
+		{
+			name:     "nothing fits",
+			amount:   10,
+			expected: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := minimalSubsets([]int{0, 1, 2, 3}, atLeast(tt.amount))
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("got=%v expected=%v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func testManagerParams(t *testing.T) cpumgrx.Params {
+	t.Helper()
+	return cpumgrx.Params{
+		PolicyName:     "static",
+		TMPolicyName:   "none",
+		MachineInfo:    testutil.ReadMachineInfo(t, "../../examples/machineinfo-v49-dualxeongold6230r.json"),
+		ReservedCPUQty: resource.MustParse("2"),
+		ReservedCPUSet: cpuset.New(0, 52),
+	}
+}
@@ -0,0 +1,245 @@
+/*
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2026 Red Hat, Inc.
+ */
+
+// Package ordering runs the same set of pods through the CPU manager
+// in different admission orders, to find out how much the outcome depends
+// on the order itself. The static policy is greedy, and after a kubelet
+// restart pods are re-admitted in an order nobody controls.
+package ordering
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+	"k8s.io/kubernetes/pkg/kubelet/cm/cpumanager/topology"
+
+	"github.com/ffromani/cpumgrx/pkg/alignment"
+	"github.com/ffromani/cpumgrx/pkg/cpumgrx"
+)
+
+const (
+	DefaultMaxOrders = 5040 // 7!
+)
+
+type Params struct {
+	Manager cpumgrx.Params
+	// MaxOrders is the maximum amount of orders to evaluate. If the pods
+	// admit more permutations than this, a random sample of distinct orders
+	// is evaluated.
+	MaxOrders int
+	Seed      int64
+}
+
+// Outcome is the result of admitting the pods in a given order.
+type Outcome struct {
+	Order      []string `json:"order"`
+	Rejected   []string `json:"rejected,omitempty"`
+	Misaligned []string `json:"misaligned,omitempty"`
+}
+
+func (oc Outcome) IsClean() bool {
+	return len(oc.Rejected) == 0 && len(oc.Misaligned) == 0
+}
+
+// PodStats tracks how a pod fared across all the evaluated orders.
+type PodStats struct {
+	Name       string `json:"name"`
+	Rejected   int    `json:"rejected"`
+	Misaligned int    `json:"misaligned"`
+	Good       int    `json:"good"`
+}
+
+func (ps PodStats) Bad() int {
+	return ps.Rejected + ps.Misaligned
+}
+
+// IsOrderSensitive tells if the pod outcome changed depending on the order.
+// Pods which always fail, or always succeed, are not sensitive to the order.
+func (ps PodStats) IsOrderSensitive() bool {
+	return ps.Bad() > 0 && ps.Good > 0
+}
+
+type Report struct {
+	Sampled   bool       `json:"sampled"`
+	Evaluated int        `json:"evaluated"`
+	Outcomes  []Outcome  `json:"outcomes"`
+	Pods      []PodStats `json:"pods"`
+}
+
+// BadOutcomes returns the outcomes in which at least one pod was rejected or misaligned.
+func (rp Report) BadOutcomes() []Outcome {
+	var res []Outcome
+	for _, oc := range rp.Outcomes {
+		if oc.IsClean() {
+			continue
+		}
+		res = append(res, oc)
+	}
+	return res
+}
+
+// MostSensitive returns the pod whose outcome changed the most depending on
+// the admission order. Returns false if no pod is sensitive to the order.
+func (rp Report) MostSensitive() (PodStats, bool) {
+	var res PodStats
+	found := false
+	for _, ps := range rp.Pods {
+		if !ps.IsOrderSensitive() {
+			continue
+		}
+		if !found || ps.Bad() > res.Bad() {
+			res = ps
+			found = true
+		}
+	}
+	return res, found
+}
+
+func Run(params Params, pods []*v1.Pod) (Report, error) {
+	topo, err := topology.Discover(params.Manager.MachineInfo)
+	if err != nil {
+		return Report{}, err
+	}
+
+	maxOrders := params.MaxOrders
+	if maxOrders <= 0 {
+		maxOrders = DefaultMaxOrders
+	}
+
+	var orders [][]int
+	rp := Report{}
+	if factorialExceeds(len(pods), maxOrders) {
+		rp.Sampled = true
+		rnd := rand.New(rand.NewSource(params.Seed))
+		seen := make(map[string]bool)
+		for len(orders) < maxOrders {
+			order := rnd.Perm(len(pods))
+			key := fmt.Sprint(order)
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			orders = append(orders, order)
+		}
+	} else {
+		orders = permutations(len(pods))
+	}
+
+	stats := make([]PodStats, len(pods))
+	for idx, pod := range pods {
+		stats[idx].Name = pod.Name
+	}
+
+	for _, order := range orders {
+		oc, err := runOrder(params.Manager, topo, pods, order, stats)
+		if err != nil {
+			return rp, err
+		}
+		rp.Outcomes = append(rp.Outcomes, oc)
+	}
+	rp.Evaluated = len(rp.Outcomes)
+	rp.Pods = stats
+	return rp, nil
+}
+
+func runOrder(params cpumgrx.Params, topo *topology.CPUTopology, pods []*v1.Pod, order []int, stats []PodStats) (Outcome, error) {
+	// each run must start from a clean state
+	params.StateFileDirectory = ""
+	mgrx, err := cpumgrx.NewFromParams(params)
+	if err != nil {
+		return Outcome{}, err
+	}
+	defer mgrx.Close()
+
+	oc := Outcome{}
+	for _, idx := range order {
+		pod := pods[idx]
+		oc.Order = append(oc.Order, pod.Name)
+
+		_, err := mgrx.Run(pod)
+		if err != nil {
+			klog.V(2).Infof("pod %q rejected: %v", pod.Name, err)
+			oc.Rejected = append(oc.Rejected, pod.Name)
+			stats[idx].Rejected++
+			continue
+		}
+
+		if isMisaligned(mgrx, topo, pod) {
+			oc.Misaligned = append(oc.Misaligned, pod.Name)
+			stats[idx].Misaligned++
+			continue
+		}
+		stats[idx].Good++
+	}
+	return oc, nil
+}
+
+// isMisaligned tells if any container of the pod got misaligned exclusive CPUs.
+func isMisaligned(mgrx *cpumgrx.CpuMgrx, topo *topology.CPUTopology, pod *v1.Pod) bool {
+	cnts := append(append([]v1.Container{}, pod.Spec.InitContainers...), pod.Spec.Containers...)
+	for _, cnt := range cnts {
+		cpus := mgrx.GetContainerExclusiveCPUs(pod, cnt.Name)
+		if cpus.Size() > 0 && !alignment.IsAligned(topo, cpus) {
+			klog.V(2).Infof("pod %q container %q misaligned: %s", pod.Name, cnt.Name, alignment.Describe(topo, cpus))
+			return true
+		}
+	}
+	return false
+}
+
+func factorialExceeds(n, limit int) bool {
+	fact := 1
+	for i := 2; i <= n; i++ {
+		fact *= i
+		if fact > limit {
+			return true
+		}
+	}
+	return false
+}
+
+// permutations returns all the permutations of [0, n) in lexicographic order
+func permutations(n int) [][]int {
+	cur := make([]int, n)
+	for i := range cur {
+		cur[i] = i
+	}
+	res := [][]int{append([]int{}, cur...)}
+	for nextPermutation(cur) {
+		res = append(res, append([]int{}, cur...))
+	}
+	return res
+}
+
+func nextPermutation(xs []int) bool {
+	i := len(xs) - 2
+	for i >= 0 && xs[i] >= xs[i+1] {
+		i--
+	}
+	if i < 0 {
+		return false
+	}
+	j := len(xs) - 1
+	for xs[j] <= xs[i] {
+		j--
+	}
+	xs[i], xs[j] = xs[j], xs[i]
+	sort.Ints(xs[i+1:])
+	return true
+}
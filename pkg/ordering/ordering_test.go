@@ -0,0 +1,176 @@
+/*
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2026 Red Hat, Inc.
+ */
+
+package ordering
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/utils/cpuset"
+
+	"github.com/ffromani/cpumgrx/internal/testutil"
+	"github.com/ffromani/cpumgrx/pkg/cpumgrx"
+)
+
+func TestPermutations(t *testing.T) {
+	tests := []struct {
+		n        int
+		expected [][]int
+	}{
+		{
+			n:        1,
+			expected: [][]int{{0}},
+		},
+		{
+			n:        2,
+			expected: [][]int{{0, 1}, {1, 0}},
+		},
+		{
+			n:        3,
+			expected: [][]int{{0, 1, 2}, {0, 2, 1}, {1, 0, 2}, {1, 2, 0}, {2, 0, 1}, {2, 1, 0}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("n=%d", tt.n), func(t *testing.T) {
+			got := permutations(tt.n)
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("got=%v expected=%v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestNextPermutation(t *testing.T) {
+	tests := []struct {
+		name     string
+		xs       []int
+		expected []int
+		more     bool
+	}{
+		{
+			name:     "swap the last two",
+			xs:       []int{0, 1, 2},
+			expected: []int{0, 2, 1},
+			more:     true,
+		},
+		{
+			name:     "carry",
+			xs:       []int{0, 2, 1},
+			expected: []int{1, 0, 2},
+			more:     true,
+		},
+		{
+			name:     "last",
+			xs:       []int{2, 1, 0},
+			expected: []int{2, 1, 0},
+			more:     false,
+		},
+		{
+			name:     "empty",
+			xs:       []int{},
+			expected: []int{},
+			more:     false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			more := nextPermutation(tt.xs)
+			if more != tt.more || !reflect.DeepEqual(tt.xs, tt.expected) {
+				t.Errorf("got=%v (%v) expected=%v (%v)", tt.xs, more, tt.expected, tt.more)
+			}
+		})
+	}
+}
+
+func TestRun(t *testing.T) {
+	testCases := []struct {
+		name               string
+		pods               []*v1.Pod
+		maxOrders          int
+		expectedSampled    bool
+		expectedEvaluated  int
+		expectedMisaligned map[string]int
+	}{
+		{
+			// whichever comes first takes the NUMA node the other one needed
+			name:               "order sensitive",
+			pods:               []*v1.Pod{testutil.MakePod("a", "30"), testutil.MakePod("b", "40")},
+			expectedEvaluated:  2,
+			expectedMisaligned: map[string]int{"a": 1, "b": 1},
+		},
+		{
+			// the first container of b is always aligned, the second one is not when a comes first
+			name:               "misaligned second container",
+			pods:               []*v1.Pod{testutil.MakePod("a", "30"), testutil.MakePod("b", "2", "40")},
+			expectedEvaluated:  2,
+			expectedMisaligned: map[string]int{"a": 1, "b": 1},
+		},
+		{
+			name:               "sampled",
+			pods:               []*v1.Pod{testutil.MakePod("a", "2"), testutil.MakePod("b", "2"), testutil.MakePod("c", "2"), testutil.MakePod("d", "2")},
+			maxOrders:          20,
+			expectedSampled:    true,
+			expectedEvaluated:  20,
+			expectedMisaligned: map[string]int{},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rp, err := Run(Params{
+				Manager:   testManagerParams(t),
+				MaxOrders: tc.maxOrders,
+				Seed:      42,
+			}, tc.pods)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if rp.Sampled != tc.expectedSampled || rp.Evaluated != tc.expectedEvaluated {
+				t.Errorf("got sampled=%v evaluated=%d expected sampled=%v evaluated=%d", rp.Sampled, rp.Evaluated, tc.expectedSampled, tc.expectedEvaluated)
+			}
+			seen := make(map[string]bool)
+			for _, oc := range rp.Outcomes {
+				key := fmt.Sprint(oc.Order)
+				if seen[key] {
+					t.Errorf("order %v evaluated twice", oc.Order)
+				}
+				seen[key] = true
+			}
+			for _, ps := range rp.Pods {
+				if ps.Misaligned != tc.expectedMisaligned[ps.Name] || ps.Rejected != 0 {
+					t.Errorf("unexpected stats for pod %q: %+v", ps.Name, ps)
+				}
+			}
+		})
+	}
+}
+
+func testManagerParams(t *testing.T) cpumgrx.Params {
+	t.Helper()
+	return cpumgrx.Params{
+		PolicyName:     "static",
+		TMPolicyName:   "none",
+		MachineInfo:    testutil.ReadMachineInfo(t, "../../examples/machineinfo-v49-dualxeongold6230r.json"),
+		ReservedCPUQty: resource.MustParse("2"),
+		ReservedCPUSet: cpuset.New(0, 52),
+	}
+}
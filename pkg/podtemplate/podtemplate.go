@@ -0,0 +1,239 @@
+/*
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2026 Red Hat, Inc.
+ */
+
+// Package podtemplate builds pods out of compact specs, for when writing manifests is too much.
+// The grammar is:
+//
+//	TEMPLATE   := NAME '=' BODY [ '*' REPLICAS ] { '@' KEY '=' VALUE }
+//	BODY       := RESOURCES | '[' CONTAINER { ',' CONTAINER } ']'
+//	CONTAINER  := [ ( 'init' | 'sidecar' ) ':' ] NAME '=' RESOURCES
+//	RESOURCES  := CPU { ':' RESOURCE '=' AMOUNT }
+//	CPU        := [ REQUEST ] '/' [ LIMIT ]
+//	AMOUNT     := QUANTITY | [ REQUEST ] '/' [ LIMIT ]
+//
+// A single QUANTITY sets both the request and the limit, while an empty REQUEST or LIMIT
+// leaves it unset. Containers get 1Gi of memory unless they set it. The pod is named
+// NAME-pod, and its only container NAME-cnt unless the containers are listed;
+// replicas are named NAME-pod-0, NAME-pod-1 and so on. Sidecars are init containers
+// which keep running along the app containers. Annotations are set on every replica.
+//
+// Examples:
+//
+//	web=4/4*10
+//	db=[main=4/4:memory=8Gi,sidecar:proxy=500m/1]
+//	dpdk=8/8:hugepages-1Gi=4Gi:example.com/nic=2@cpu-load-balancing.crio.io=disable
+package podtemplate
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/util/uuid"
+)
+
+const (
+	kindInit    = "init"
+	kindSidecar = "sidecar"
+)
+
+var defaultMemory = resource.MustParse("1Gi")
+
+// ParseAll returns the pods described by all the templates, in order.
+func ParseAll(specs []string) ([]*v1.Pod, error) {
+	var pods []*v1.Pod
+	for _, spec := range specs {
+		specPods, err := Parse(spec)
+		if err != nil {
+			return nil, err
+		}
+		pods = append(pods, specPods...)
+	}
+	return pods, nil
+}
+
+// Parse returns the pods described by the template, each with its own UID.
+func Parse(spec string) ([]*v1.Pod, error) {
+	pods, err := parse(spec)
+	if err != nil {
+		return nil, fmt.Errorf("bad pod template %q: %w", spec, err)
+	}
+	return pods, nil
+}
+
+func parse(spec string) ([]*v1.Pod, error) {
+	body, rawAnnotations, hasAnnotations := strings.Cut(spec, "@")
+	var annotations map[string]string
+	var err error
+	if hasAnnotations {
+		annotations, err = parseAnnotations(rawAnnotations)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	name, body, ok := strings.Cut(body, "=")
+	if !ok || name == "" {
+		return nil, fmt.Errorf("expected NAME=BODY")
+	}
+
+	replicas := 1
+	if idx := strings.LastIndex(body, "*"); idx != -1 {
+		replicas, err = strconv.Atoi(body[idx+1:])
+		if err != nil || replicas < 1 {
+			return nil, fmt.Errorf("bad replica count %q", body[idx+1:])
+		}
+		body = body[:idx]
+	}
+
+	tmpl := v1.Pod{}
+	tmpl.Name = name + "-pod"
+	tmpl.Annotations = annotations
+	if strings.HasPrefix(body, "[") {
+		if !strings.HasSuffix(body, "]") {
+			return nil, fmt.Errorf("unterminated container list")
+		}
+		if err := parseContainers(&tmpl.Spec, body[1:len(body)-1]); err != nil {
+			return nil, err
+		}
+	} else {
+		res, err := parseResources(body)
+		if err != nil {
+			return nil, err
+		}
+		tmpl.Spec.Containers = []v1.Container{{Name: name + "-cnt", Resources: res}}
+	}
+
+	var pods []*v1.Pod
+	for idx := 0; idx < replicas; idx++ {
+		pod := tmpl.DeepCopy()
+		if replicas > 1 {
+			pod.Name = fmt.Sprintf("%s-%d", tmpl.Name, idx)
+		}
+		pod.UID = uuid.NewUUID()
+		pods = append(pods, pod)
+	}
+	return pods, nil
+}
+
+func parseAnnotations(raw string) (map[string]string, error) {
+	annotations := make(map[string]string)
+	for _, item := range strings.Split(raw, "@") {
+		key, value, ok := strings.Cut(item, "=")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("bad annotation %q, expected KEY=VALUE", item)
+		}
+		annotations[key] = value
+	}
+	return annotations, nil
+}
+
+func parseContainers(spec *v1.PodSpec, raw string) error {
+	if raw == "" {
+		return fmt.Errorf("empty container list")
+	}
+	for _, item := range strings.Split(raw, ",") {
+		kind := ""
+		if prefix, rest, ok := strings.Cut(item, ":"); ok && (prefix == kindInit || prefix == kindSidecar) {
+			kind, item = prefix, rest
+		}
+		name, rawRes, ok := strings.Cut(item, "=")
+		if !ok || name == "" {
+			return fmt.Errorf("bad container %q, expected NAME=RESOURCES", item)
+		}
+		res, err := parseResources(rawRes)
+		if err != nil {
+			return fmt.Errorf("container %q: %w", name, err)
+		}
+		cnt := v1.Container{Name: name, Resources: res}
+		switch kind {
+		case kindInit:
+			spec.InitContainers = append(spec.InitContainers, cnt)
+		case kindSidecar:
+			restartPolicy := v1.ContainerRestartPolicyAlways
+			cnt.RestartPolicy = &restartPolicy
+			spec.InitContainers = append(spec.InitContainers, cnt)
+		default:
+			spec.Containers = append(spec.Containers, cnt)
+		}
+	}
+	if len(spec.Containers) == 0 {
+		return fmt.Errorf("no app containers")
+	}
+	return nil
+}
+
+func parseResources(raw string) (v1.ResourceRequirements, error) {
+	res := v1.ResourceRequirements{
+		Requests: make(v1.ResourceList),
+		Limits:   make(v1.ResourceList),
+	}
+	items := strings.Split(raw, ":")
+	rawCPU := items[0]
+	if !strings.Contains(rawCPU, "/") {
+		return res, fmt.Errorf("bad cpu amount %q, expected REQUEST/LIMIT", rawCPU)
+	}
+	if err := setAmount(res, v1.ResourceCPU, rawCPU); err != nil {
+		return res, err
+	}
+	setMemory := false
+	for _, item := range items[1:] {
+		name, amount, ok := strings.Cut(item, "=")
+		if !ok || name == "" || amount == "" {
+			return res, fmt.Errorf("bad resource %q, expected RESOURCE=AMOUNT", item)
+		}
+		resName := v1.ResourceName(name)
+		if resName == v1.ResourceCPU {
+			return res, fmt.Errorf("cpu amount given twice")
+		}
+		if err := setAmount(res, resName, amount); err != nil {
+			return res, err
+		}
+		if resName == v1.ResourceMemory {
+			setMemory = true
+		}
+	}
+	if !setMemory {
+		res.Requests[v1.ResourceMemory] = defaultMemory
+		res.Limits[v1.ResourceMemory] = defaultMemory
+	}
+	return res, nil
+}
+
+// setAmount parses either QUANTITY, setting both the request and the limit, or REQUEST/LIMIT.
+func setAmount(res v1.ResourceRequirements, name v1.ResourceName, amount string) error {
+	rawReq, rawLim, split := strings.Cut(amount, "/")
+	if !split {
+		rawLim = rawReq
+	}
+	if rawReq != "" {
+		qty, err := resource.ParseQuantity(rawReq)
+		if err != nil {
+			return fmt.Errorf("bad %s request %q: %w", name, rawReq, err)
+		}
+		res.Requests[name] = qty
+	}
+	if rawLim != "" {
+		qty, err := resource.ParseQuantity(rawLim)
+		if err != nil {
+			return fmt.Errorf("bad %s limit %q: %w", name, rawLim, err)
+		}
+		res.Limits[name] = qty
+	}
+	return nil
+}
@@ -0,0 +1,139 @@
+/*
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2026 Red Hat, Inc.
+ */
+
+package podtemplate
+
+import (
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	v1qos "k8s.io/kubernetes/pkg/apis/core/v1/helper/qos"
+)
+
+func TestParse(t *testing.T) {
+	pods, err := Parse("web=2/4")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(pods) != 1 || pods[0].Name != "web-pod" || pods[0].UID == "" {
+		t.Fatalf("unexpected pods: %v", pods)
+	}
+	cnt := pods[0].Spec.Containers[0]
+	if cnt.Name != "web-cnt" {
+		t.Errorf("unexpected container name %q", cnt.Name)
+	}
+	expectQuantity(t, cnt.Resources.Requests, v1.ResourceCPU, "2")
+	expectQuantity(t, cnt.Resources.Limits, v1.ResourceCPU, "4")
+	expectQuantity(t, cnt.Resources.Requests, v1.ResourceMemory, "1Gi")
+	expectQuantity(t, cnt.Resources.Limits, v1.ResourceMemory, "1Gi")
+}
+
+func TestParseReplicas(t *testing.T) {
+	pods, err := Parse("web=4/4*3")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(pods) != 3 {
+		t.Fatalf("got %d pods expected 3", len(pods))
+	}
+	for idx, name := range []string{"web-pod-0", "web-pod-1", "web-pod-2"} {
+		if pods[idx].Name != name {
+			t.Errorf("pod %d: got name %q expected %q", idx, pods[idx].Name, name)
+		}
+	}
+	if pods[0].UID == pods[1].UID {
+		t.Errorf("replicas share the UID %q", pods[0].UID)
+	}
+}
+
+func TestParseContainers(t *testing.T) {
+	pods, err := Parse("db=[init:setup=1/1,main=4/4:memory=8Gi:hugepages-1Gi=2Gi:example.com/nic=1,sidecar:proxy=500m/1]@cpu-load-balancing.crio.io=disable")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	pod := pods[0]
+	if len(pod.Spec.InitContainers) != 2 || len(pod.Spec.Containers) != 1 {
+		t.Fatalf("unexpected containers: %+v", pod.Spec)
+	}
+	if pod.Spec.InitContainers[0].RestartPolicy != nil {
+		t.Errorf("init container restarts")
+	}
+	if rp := pod.Spec.InitContainers[1].RestartPolicy; rp == nil || *rp != v1.ContainerRestartPolicyAlways {
+		t.Errorf("sidecar container doesn't restart")
+	}
+	main := pod.Spec.Containers[0]
+	if main.Name != "main" {
+		t.Errorf("unexpected container name %q", main.Name)
+	}
+	expectQuantity(t, main.Resources.Limits, v1.ResourceMemory, "8Gi")
+	expectQuantity(t, main.Resources.Requests, "hugepages-1Gi", "2Gi")
+	expectQuantity(t, main.Resources.Limits, "example.com/nic", "1")
+	if got := pod.Annotations["cpu-load-balancing.crio.io"]; got != "disable" {
+		t.Errorf("unexpected annotation %q", got)
+	}
+	// the sidecar makes the pod burstable
+	if qosClass := v1qos.GetPodQOS(pod); qosClass != v1.PodQOSBurstable {
+		t.Errorf("got QoS class %s", qosClass)
+	}
+}
+
+func TestParseBestEffort(t *testing.T) {
+	pods, err := Parse("be=/:memory=/")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if qosClass := v1qos.GetPodQOS(pods[0]); qosClass != v1.PodQOSBestEffort {
+		t.Errorf("got QoS class %s", qosClass)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	specs := []string{
+		"",
+		"web",
+		"=4/4",
+		"web=4",
+		"web=x/4",
+		"web=4/4*0",
+		"web=4/4*many",
+		"web=4/4:memory",
+		"web=4/4:memory=",
+		"web=4/4:cpu=2",
+		"web=[main=4/4",
+		"web=[]",
+		"web=[init:setup=1/1]",
+		"web=[main]",
+		"web=4/4@",
+	}
+	for _, spec := range specs {
+		if pods, err := Parse(spec); err == nil {
+			t.Errorf("%q: expected error, got pods %v", spec, pods)
+		}
+	}
+}
+
+func expectQuantity(t *testing.T, rl v1.ResourceList, name v1.ResourceName, expected string) {
+	t.Helper()
+	got, ok := rl[name]
+	if !ok {
+		t.Errorf("missing %s", name)
+		return
+	}
+	if got.Cmp(resource.MustParse(expected)) != 0 {
+		t.Errorf("%s: got %s expected %s", name, got.String(), expected)
+	}
+}
@@ -0,0 +1,268 @@
+/*
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2026 Red Hat, Inc.
+ */
+
+// Package reservedset checks reserved CPU sets against the machine topology, and proposes good ones.
+// A good reserved set takes whole cores, so no sibling thread is left out of the exclusive allocations
+// under full-pcpus-only, sits on one uncore cache per NUMA node, and either stays on one NUMA node
+// or spreads evenly across all of them, as asked.
+package reservedset
+
+import (
+	"fmt"
+	"sort"
+
+	"k8s.io/kubernetes/pkg/kubelet/cm/cpumanager/topology"
+	"k8s.io/utils/cpuset"
+)
+
+// Layout is how the reserved CPUs should sit across NUMA nodes.
+type Layout string
+
+const (
+	// LayoutPacked keeps the reserved CPUs on as few NUMA nodes as possible
+	LayoutPacked Layout = "packed"
+	// LayoutSpread splits the reserved CPUs evenly across all the NUMA nodes
+	LayoutSpread Layout = "spread"
+)
+
+// Problem is a kind of flaw of a reserved set.
+type Problem string
+
+const (
+	// ProblemUnknownCPUs means the set holds CPUs the machine doesn't have
+	ProblemUnknownCPUs Problem = "unknown CPUs"
+	// ProblemSplitCores means the set holds only some threads of a core
+	ProblemSplitCores Problem = "split cores"
+	// ProblemStraddlesNUMA means a packed set spans more NUMA nodes than it needs
+	ProblemStraddlesNUMA Problem = "straddles NUMA nodes"
+	// ProblemUnevenNUMA means a spread set doesn't take the same amount of CPUs from each NUMA node
+	ProblemUnevenNUMA Problem = "uneven across NUMA nodes"
+	// ProblemUncoreCaches means the set spans more than one uncore cache on a NUMA node
+	ProblemUncoreCaches Problem = "straddles uncore caches"
+)
+
+// penalties rank the problems: split cores waste CPUs, the others only locality.
+var penalties = map[Problem]int{
+	ProblemUnknownCPUs:   1000,
+	ProblemSplitCores:    100,
+	ProblemStraddlesNUMA: 10,
+	ProblemUnevenNUMA:    10,
+	ProblemUncoreCaches:  1,
+}
+
+// Issue is a flaw found in a reserved set.
+type Issue struct {
+	Problem Problem `json:"problem"`
+	Message string  `json:"message"`
+}
+
+func (is Issue) String() string {
+	return string(is.Problem) + ": " + is.Message
+}
+
+// Candidate is a proposed reserved set, with the flaws it can't avoid.
+type Candidate struct {
+	CPUs    cpuset.CPUSet `json:"cpus"`
+	Issues  []Issue       `json:"issues,omitempty"`
+	Penalty int           `json:"penalty"`
+}
+
+// Validate returns the flaws of the reserved set, worst first.
+func Validate(topo *topology.CPUTopology, reserved cpuset.CPUSet, layout Layout) []Issue {
+	details := topo.CPUDetails
+	var issues []Issue
+	if unknown := reserved.Difference(details.CPUs()); !unknown.IsEmpty() {
+		issues = append(issues, Issue{
+			Problem: ProblemUnknownCPUs,
+			Message: fmt.Sprintf("CPUs %q are not on the machine", unknown.String()),
+		})
+		reserved = reserved.Intersection(details.CPUs())
+	}
+
+	reservedDetails := details.KeepOnly(reserved)
+	for _, coreID := range reservedDetails.Cores().List() {
+		siblings := details.CPUsInCores(coreID).Difference(reserved)
+		if siblings.IsEmpty() {
+			continue
+		}
+		issues = append(issues, Issue{
+			Problem: ProblemSplitCores,
+			Message: fmt.Sprintf("core %d is partly reserved: with full-pcpus-only its threads %q can't be allocated exclusively", coreID, siblings.String()),
+		})
+	}
+
+	numaNodes := reservedDetails.NUMANodes()
+	switch layout {
+	case LayoutSpread:
+		if reserved.Size() < details.NUMANodes().Size() {
+			break
+		}
+		minCPUs, maxCPUs := reserved.Size(), 0
+		for _, numaID := range details.NUMANodes().List() {
+			numCPUs := reservedDetails.CPUsInNUMANodes(numaID).Size()
+			minCPUs, maxCPUs = min(minCPUs, numCPUs), max(maxCPUs, numCPUs)
+		}
+		// an odd amount of cores can't be split evenly
+		if maxCPUs-minCPUs > topo.CPUsPerCore() {
+			issues = append(issues, Issue{
+				Problem: ProblemUnevenNUMA,
+				Message: fmt.Sprintf("from %d to %d CPUs per NUMA node", minCPUs, maxCPUs),
+			})
+		}
+	default:
+		if needed := numaNodesNeeded(topo, reserved.Size()); numaNodes.Size() > needed {
+			issues = append(issues, Issue{
+				Problem: ProblemStraddlesNUMA,
+				Message: fmt.Sprintf("spans NUMA nodes %q, while %d would do", numaNodes.String(), needed),
+			})
+		}
+	}
+
+	for _, numaID := range numaNodes.List() {
+		caches := reservedDetails.UncoreInNUMANodes(numaID)
+		if caches.Size() <= uncoreCachesNeeded(topo, reservedDetails.CPUsInNUMANodes(numaID).Size()) {
+			continue
+		}
+		issues = append(issues, Issue{
+			Problem: ProblemUncoreCaches,
+			Message: fmt.Sprintf("spans uncore caches %q on NUMA node %d", caches.String(), numaID),
+		})
+	}
+
+	sort.SliceStable(issues, func(i, j int) bool {
+		return penalties[issues[i].Problem] > penalties[issues[j].Problem]
+	})
+	return issues
+}
+
+// Propose returns reserved sets of the given size, best first, at most maxCandidates of them.
+func Propose(topo *topology.CPUTopology, size int, layout Layout, maxCandidates int) ([]Candidate, error) {
+	details := topo.CPUDetails
+	if size < 1 || size > details.CPUs().Size() {
+		return nil, fmt.Errorf("cannot reserve %d CPUs out of %d", size, details.CPUs().Size())
+	}
+
+	var sets []cpuset.CPUSet
+	numaIDs := details.NUMANodes().List()
+	if layout == LayoutSpread {
+		// the i-th uncore cache of each NUMA node
+		for idx := 0; idx < maxUncoreCachesPerNUMANode(details); idx++ {
+			sets = append(sets, takeSpread(topo, size, idx))
+		}
+	} else {
+		for _, numaID := range numaIDs {
+			for _, cacheID := range details.UncoreInNUMANodes(numaID).List() {
+				sets = append(sets, takePacked(topo, size, numaID, cacheID))
+			}
+		}
+	}
+
+	var candidates []Candidate
+	seen := make(map[string]bool)
+	for _, cpus := range sets {
+		if seen[cpus.String()] {
+			continue
+		}
+		seen[cpus.String()] = true
+		cand := Candidate{
+			CPUs:   cpus,
+			Issues: Validate(topo, cpus, layout),
+		}
+		for _, is := range cand.Issues {
+			cand.Penalty += penalties[is.Problem]
+		}
+		candidates = append(candidates, cand)
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].Penalty < candidates[j].Penalty
+	})
+	if maxCandidates > 0 && len(candidates) > maxCandidates {
+		candidates = candidates[:maxCandidates]
+	}
+	return candidates, nil
+}
+
+// takePacked takes whole cores starting from the uncore cache, then from the rest of the NUMA node, then from the others.
+func takePacked(topo *topology.CPUTopology, size int, numaID, cacheID int) cpuset.CPUSet {
+	details := topo.CPUDetails
+	cacheCores := details.KeepOnly(details.CPUsInUncoreCaches(cacheID)).Cores()
+	numaCores := details.CoresInNUMANodes(numaID).Difference(cacheCores)
+	otherCores := details.Cores().Difference(cacheCores).Difference(numaCores)
+	var cores []int
+	cores = append(cores, cacheCores.List()...)
+	cores = append(cores, numaCores.List()...)
+	cores = append(cores, otherCores.List()...)
+	return takeCores(details, cores, size)
+}
+
+// takeSpread takes whole cores evenly from each NUMA node, starting from the idx-th uncore cache of each.
+func takeSpread(topo *topology.CPUTopology, size int, idx int) cpuset.CPUSet {
+	details := topo.CPUDetails
+	numaIDs := details.NUMANodes().List()
+	cpusPerCore := topo.CPUsPerCore()
+	numCores := (size + cpusPerCore - 1) / cpusPerCore
+	cpus := cpuset.New()
+	for pos, numaID := range numaIDs {
+		numaCores := numCores / len(numaIDs)
+		if pos < numCores%len(numaIDs) {
+			numaCores++
+		}
+		numaSize := min(numaCores*cpusPerCore, size-cpus.Size())
+		caches := details.UncoreInNUMANodes(numaID).List()
+		cacheID := caches[idx%len(caches)]
+		cacheCores := details.KeepOnly(details.CPUsInUncoreCaches(cacheID)).Cores()
+		var cores []int
+		cores = append(cores, cacheCores.List()...)
+		cores = append(cores, details.CoresInNUMANodes(numaID).Difference(cacheCores).List()...)
+		cpus = cpus.Union(takeCores(details, cores, numaSize))
+	}
+	return cpus
+}
+
+// takeCores takes the threads of the cores in order, until it has enough of them.
+func takeCores(details topology.CPUDetails, cores []int, size int) cpuset.CPUSet {
+	var taken []int
+	for _, coreID := range cores {
+		for _, cpuID := range details.CPUsInCores(coreID).List() {
+			if len(taken) == size {
+				return cpuset.New(taken...)
+			}
+			taken = append(taken, cpuID)
+		}
+	}
+	return cpuset.New(taken...)
+}
+
+func numaNodesNeeded(topo *topology.CPUTopology, size int) int {
+	cpusPerNUMANode := topo.NumCPUs / topo.NumNUMANodes
+	return (size + cpusPerNUMANode - 1) / cpusPerNUMANode
+}
+
+func uncoreCachesNeeded(topo *topology.CPUTopology, size int) int {
+	cpusPerUncore := topo.CPUsPerUncore()
+	if cpusPerUncore == 0 {
+		return 1
+	}
+	return (size + cpusPerUncore - 1) / cpusPerUncore
+}
+
+func maxUncoreCachesPerNUMANode(details topology.CPUDetails) int {
+	res := 1
+	for _, numaID := range details.NUMANodes().List() {
+		res = max(res, details.UncoreInNUMANodes(numaID).Size())
+	}
+	return res
+}
@@ -0,0 +1,159 @@
+/*
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2026 Red Hat, Inc.
+ */
+
+package reservedset
+
+import (
+	"reflect"
+	"testing"
+
+	"k8s.io/kubernetes/pkg/kubelet/cm/cpumanager/topology"
+	"k8s.io/utils/cpuset"
+
+	"github.com/ffromani/cpumgrx/internal/testutil"
+)
+
+func TestValidate(t *testing.T) {
+	ryzen := testutil.Discover(t, "../../examples/machineinfo-v49-ryzen5950x.json")
+	xeon := testutil.Discover(t, "../../examples/machineinfo-v49-dualxeongold6230r.json")
+
+	testCases := []struct {
+		name     string
+		topo     *topology.CPUTopology
+		reserved cpuset.CPUSet
+		layout   Layout
+		expected []Problem
+	}{
+		{
+			name:     "whole core",
+			topo:     ryzen,
+			reserved: cpuset.New(0, 16),
+			layout:   LayoutPacked,
+		},
+		{
+			name:     "split cores",
+			topo:     ryzen,
+			reserved: cpuset.New(0, 1),
+			layout:   LayoutPacked,
+			expected: []Problem{ProblemSplitCores, ProblemSplitCores},
+		},
+		{
+			name:     "two uncore caches",
+			topo:     ryzen,
+			reserved: cpuset.New(0, 8, 16, 24),
+			layout:   LayoutPacked,
+			expected: []Problem{ProblemUncoreCaches},
+		},
+		{
+			name:     "unknown CPUs",
+			topo:     ryzen,
+			reserved: cpuset.New(0, 16, 64),
+			layout:   LayoutPacked,
+			expected: []Problem{ProblemUnknownCPUs},
+		},
+		{
+			name:     "straddles NUMA nodes",
+			topo:     xeon,
+			reserved: cpuset.New(0, 1, 52, 53),
+			layout:   LayoutPacked,
+			expected: []Problem{ProblemStraddlesNUMA},
+		},
+		{
+			name:     "spread",
+			topo:     xeon,
+			reserved: cpuset.New(0, 1, 52, 53),
+			layout:   LayoutSpread,
+		},
+		{
+			name:     "uneven",
+			topo:     xeon,
+			reserved: cpuset.New(0, 2, 4, 52, 54, 56),
+			layout:   LayoutSpread,
+			expected: []Problem{ProblemUnevenNUMA},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var got []Problem
+			for _, is := range Validate(tc.topo, tc.reserved, tc.layout) {
+				got = append(got, is.Problem)
+			}
+			if !reflect.DeepEqual(got, tc.expected) {
+				t.Errorf("got problems %v expected %v", got, tc.expected)
+			}
+		})
+	}
+}
+
+func TestPropose(t *testing.T) {
+	ryzen := testutil.Discover(t, "../../examples/machineinfo-v49-ryzen5950x.json")
+	xeon := testutil.Discover(t, "../../examples/machineinfo-v49-dualxeongold6230r.json")
+
+	testCases := []struct {
+		name     string
+		topo     *topology.CPUTopology
+		size     int
+		layout   Layout
+		expected cpuset.CPUSet
+	}{
+		{
+			name:     "packed",
+			topo:     xeon,
+			size:     4,
+			layout:   LayoutPacked,
+			expected: cpuset.New(0, 2, 52, 54),
+		},
+		{
+			name:     "spread",
+			topo:     xeon,
+			size:     4,
+			layout:   LayoutSpread,
+			expected: cpuset.New(0, 1, 52, 53),
+		},
+		{
+			name:     "odd size",
+			topo:     ryzen,
+			size:     3,
+			layout:   LayoutPacked,
+			expected: cpuset.New(0, 1, 16),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			candidates, err := Propose(tc.topo, tc.size, tc.layout, 0)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(candidates) == 0 {
+				t.Fatalf("no candidates")
+			}
+			if !candidates[0].CPUs.Equals(tc.expected) {
+				t.Errorf("got best %q expected %q", candidates[0].CPUs.String(), tc.expected.String())
+			}
+			for idx := 1; idx < len(candidates); idx++ {
+				if candidates[idx].Penalty < candidates[idx-1].Penalty {
+					t.Errorf("candidates not ranked: %+v", candidates)
+				}
+			}
+		})
+	}
+
+	if _, err := Propose(ryzen, 33, LayoutPacked, 0); err == nil {
+		t.Errorf("proposed more CPUs than the machine has")
+	}
+}
@@ -0,0 +1,195 @@
+/*
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2026 Red Hat, Inc.
+ */
+
+package server
+
+import (
+	"errors"
+	"net/http"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/uuid"
+	extenderv1 "k8s.io/kube-scheduler/extender/v1"
+	"k8s.io/kubernetes/pkg/kubelet/cm/cpumanager/topology"
+	"k8s.io/utils/cpuset"
+
+	"github.com/ffromani/cpumgrx/pkg/alignment"
+	"github.com/ffromani/cpumgrx/pkg/cpumgrx"
+)
+
+// The scheduler extender endpoints predict what the kubelet of each candidate
+// node would do with the pod. The node models are the sessions: a session
+// named after the node holds its machine info, config and current pods.
+// Nodes we have no model for are let through and get a neutral score,
+// because we can't predict anything about them.
+
+var errMissingPod = errors.New("missing pod or pod containers")
+
+// prediction is the outcome of the pod admission on a node model.
+type prediction struct {
+	cpumgrx.AdmitResult
+	score int64
+	err   error
+}
+
+func (srv *Server) extenderFilter(w http.ResponseWriter, r *http.Request) {
+	var args extenderv1.ExtenderArgs
+	if !decodeRequest(w, r, &args) {
+		return
+	}
+	if args.Pod == nil || len(args.Pod.Spec.Containers) == 0 {
+		writeJSON(w, http.StatusOK, extenderv1.ExtenderFilterResult{Error: errMissingPod.Error()})
+		return
+	}
+
+	res := extenderv1.ExtenderFilterResult{
+		FailedNodes:                extenderv1.FailedNodesMap{},
+		FailedAndUnresolvableNodes: extenderv1.FailedNodesMap{},
+	}
+	passed := make(map[string]bool)
+	for nodeName, pred := range srv.predictAll(args.Pod, extenderNodeNames(args)) {
+		switch {
+		case pred == nil:
+			passed[nodeName] = true
+		case pred.err != nil:
+			res.FailedNodes[nodeName] = pred.err.Error()
+		case pred.Admit:
+			passed[nodeName] = true
+		case pred.Cause == cpumgrx.CauseSMTAlignment, pred.Cause == cpumgrx.CauseInvalidRequest:
+			// neither the SMT level of the node nor the pod spec change evicting pods
+			res.FailedAndUnresolvableNodes[nodeName] = pred.String()
+		default:
+			res.FailedNodes[nodeName] = pred.String()
+		}
+	}
+
+	if args.NodeNames != nil {
+		nodeNames := []string{}
+		for _, nodeName := range *args.NodeNames {
+			if passed[nodeName] {
+				nodeNames = append(nodeNames, nodeName)
+			}
+		}
+		res.NodeNames = &nodeNames
+	}
+	if args.Nodes != nil {
+		nodes := &v1.NodeList{}
+		for _, node := range args.Nodes.Items {
+			if passed[node.Name] {
+				nodes.Items = append(nodes.Items, node)
+			}
+		}
+		res.Nodes = nodes
+	}
+	writeJSON(w, http.StatusOK, res)
+}
+
+func (srv *Server) extenderPrioritize(w http.ResponseWriter, r *http.Request) {
+	var args extenderv1.ExtenderArgs
+	if !decodeRequest(w, r, &args) {
+		return
+	}
+	if args.Pod == nil || len(args.Pod.Spec.Containers) == 0 {
+		writeError(w, http.StatusBadRequest, errMissingPod)
+		return
+	}
+
+	nodeNames := extenderNodeNames(args)
+	preds := srv.predictAll(args.Pod, nodeNames)
+	res := extenderv1.HostPriorityList{}
+	for _, nodeName := range nodeNames {
+		score := extenderv1.MaxExtenderPriority / 2
+		if pred := preds[nodeName]; pred != nil {
+			score = pred.score
+		}
+		res = append(res, extenderv1.HostPriority{
+			Host:  nodeName,
+			Score: score,
+		})
+	}
+	writeJSON(w, http.StatusOK, res)
+}
+
+// predictAll returns the prediction for each node, nil if the node has no model.
+func (srv *Server) predictAll(pod *v1.Pod, nodeNames []string) map[string]*prediction {
+	if pod.UID == "" {
+		pod.UID = uuid.NewUUID()
+	}
+	preds := make(map[string]*prediction)
+	for _, nodeName := range nodeNames {
+		srv.lock.Lock()
+		sess, ok := srv.sessions[nodeName]
+		srv.lock.Unlock()
+		if !ok {
+			preds[nodeName] = nil
+			continue
+		}
+		preds[nodeName] = sess.predict(pod)
+	}
+	return preds
+}
+
+// alignmentScore grades the exclusive CPUs against the best allocation of the
+// same size, following the alignment.Penalty weights. Shared CPUs don't need
+// any alignment, so they always get the top score.
+func alignmentScore(topo *topology.CPUTopology, cpus cpuset.CPUSet) int64 {
+	if cpus.IsEmpty() {
+		return extenderv1.MaxExtenderPriority
+	}
+	penalty := alignment.Penalty(alignment.Describe(topo, cpus), alignment.Best(topo, cpus.Size()))
+	switch {
+	case penalty == 0:
+		return extenderv1.MaxExtenderPriority
+	case penalty < 100: // extra uncore caches only
+		return extenderv1.MaxExtenderPriority - 1
+	case penalty < 10000: // split cores
+		return extenderv1.MaxExtenderPriority / 2
+	default: // extra NUMA nodes
+		return extenderv1.MinExtenderPriority + 1
+	}
+}
+
+// predict admits the pod and removes it right away, leaving the session as it was.
+func (sess *session) predict(pod *v1.Pod) *prediction {
+	sess.lock.Lock()
+	defer sess.lock.Unlock()
+	res, err := sess.mgrx.Run(pod)
+	pred := &prediction{
+		AdmitResult: res.AdmitResult,
+		score:       extenderv1.MinExtenderPriority,
+	}
+	if err != nil {
+		return pred
+	}
+	pred.score = alignmentScore(sess.topo, sess.mgrx.GetPodExclusiveCPUs(pod))
+	if pred.err = sess.mgrx.Remove(pod); pred.err != nil {
+		pred.score = extenderv1.MinExtenderPriority
+	}
+	return pred
+}
+
+func extenderNodeNames(args extenderv1.ExtenderArgs) []string {
+	if args.NodeNames != nil {
+		return *args.NodeNames
+	}
+	var nodeNames []string
+	if args.Nodes != nil {
+		for _, node := range args.Nodes.Items {
+			nodeNames = append(nodeNames, node.Name)
+		}
+	}
+	return nodeNames
+}
@@ -0,0 +1,119 @@
+/*
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2026 Red Hat, Inc.
+ */
+
+package server
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	extenderv1 "k8s.io/kube-scheduler/extender/v1"
+)
+
+func TestExtender(t *testing.T) {
+	ts := newTestServer(t)
+
+	plain := testNodeSpec(t)
+	smt := testNodeSpec(t)
+	smt.Config.CPUManagerPolicyOptions = map[string]string{"full-pcpus-only": "true"}
+	for name, spec := range map[string]NodeSpec{"plain": plain, "smt": smt} {
+		code := doRequest(t, ts, http.MethodPost, "/v1/sessions", CreateSessionRequest{Name: name, NodeSpec: spec}, nil)
+		if code != http.StatusCreated {
+			t.Fatalf("session %q: unexpected status %d", name, code)
+		}
+	}
+	// "unknown" has no model
+	nodeNames := []string{"plain", "smt", "unknown"}
+
+	var filterRes extenderv1.ExtenderFilterResult
+	code := doRequest(t, ts, http.MethodPost, "/v1/extender/filter", extenderv1.ExtenderArgs{
+		Pod:       makeExtenderPod("3"),
+		NodeNames: &nodeNames,
+	}, &filterRes)
+	if code != http.StatusOK {
+		t.Fatalf("filter: unexpected status %d", code)
+	}
+	if filterRes.NodeNames == nil || !reflect.DeepEqual(*filterRes.NodeNames, []string{"plain", "unknown"}) {
+		t.Errorf("filter: unexpected nodes: %v", filterRes.NodeNames)
+	}
+	if _, ok := filterRes.FailedAndUnresolvableNodes["smt"]; !ok {
+		t.Errorf("filter: node %q not failed: %+v", "smt", filterRes)
+	}
+
+	// the kubelet would never see it, the API server rejects it
+	invalid := makeExtenderPod("2")
+	invalid.Spec.Containers[0].Resources.Requests[v1.ResourceCPU] = resource.MustParse("4")
+	filterRes = extenderv1.ExtenderFilterResult{}
+	code = doRequest(t, ts, http.MethodPost, "/v1/extender/filter", extenderv1.ExtenderArgs{
+		Pod:       invalid,
+		NodeNames: &nodeNames,
+	}, &filterRes)
+	if code != http.StatusOK {
+		t.Fatalf("filter: unexpected status %d", code)
+	}
+	if filterRes.NodeNames == nil || !reflect.DeepEqual(*filterRes.NodeNames, []string{"unknown"}) {
+		t.Errorf("filter invalid pod: unexpected nodes: %v", filterRes.NodeNames)
+	}
+
+	var prioRes extenderv1.HostPriorityList
+	code = doRequest(t, ts, http.MethodPost, "/v1/extender/prioritize", extenderv1.ExtenderArgs{
+		Pod:       makeExtenderPod("4"),
+		NodeNames: &nodeNames,
+	}, &prioRes)
+	if code != http.StatusOK {
+		t.Fatalf("prioritize: unexpected status %d", code)
+	}
+	expected := extenderv1.HostPriorityList{
+		{Host: "plain", Score: extenderv1.MaxExtenderPriority},
+		{Host: "smt", Score: extenderv1.MaxExtenderPriority},
+		{Host: "unknown", Score: extenderv1.MaxExtenderPriority / 2},
+	}
+	if !reflect.DeepEqual(prioRes, expected) {
+		t.Errorf("prioritize: got=%v expected=%v", prioRes, expected)
+	}
+
+	// the predictions must not leave anything behind
+	var st SessionStatus
+	doRequest(t, ts, http.MethodGet, "/v1/sessions/plain", nil, &st)
+	if len(st.Pods) != 0 || st.FreeCPUs != "1-31" {
+		t.Errorf("session changed: %+v", st)
+	}
+}
+
+func makeExtenderPod(cpus string) *v1.Pod {
+	res := v1.ResourceList{
+		v1.ResourceCPU:    resource.MustParse(cpus),
+		v1.ResourceMemory: resource.MustParse("1Gi"),
+	}
+	pod := &v1.Pod{
+		Spec: v1.PodSpec{
+			Containers: []v1.Container{
+				{
+					Name: "cnt",
+					Resources: v1.ResourceRequirements{
+						Limits:   res.DeepCopy(),
+						Requests: res.DeepCopy(),
+					},
+				},
+			},
+		},
+	}
+	pod.Name = "pod-" + cpus
+	return pod
+}
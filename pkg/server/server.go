@@ -0,0 +1,507 @@
+/*
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2026 Red Hat, Inc.
+ */
+
+// Package server exposes the simulations over a local HTTP JSON API.
+// One-shot simulations run against a throwaway manager; named sessions keep
+// their manager around, so pods can be added and removed step by step.
+// Each session owns its state, so requests against different sessions run
+// concurrently, while requests against the same session are serialized.
+// Sessions also serve as node models for the scheduler extender endpoints.
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	cadvisorapi "github.com/google/cadvisor/info/v1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/uuid"
+	utilfeature "k8s.io/apiserver/pkg/util/feature"
+	"k8s.io/component-base/featuregate"
+	"k8s.io/klog/v2"
+	v1qos "k8s.io/kubernetes/pkg/apis/core/v1/helper/qos"
+	"k8s.io/kubernetes/pkg/kubelet/cm/cpumanager/topology"
+
+	"github.com/ffromani/cpumgrx/pkg/cpumgrx"
+	"github.com/ffromani/cpumgrx/pkg/klogcapture"
+	"github.com/ffromani/cpumgrx/pkg/kubeletconfig"
+	"github.com/ffromani/cpumgrx/pkg/workload"
+)
+
+// maxBodySize bounds the request bodies. Machine infos of big boxes are a few hundreds KiBs.
+const maxBodySize = 16 << 20
+
+// NodeSpec describes the node to simulate.
+type NodeSpec struct {
+	MachineInfo *cadvisorapi.MachineInfo `json:"machineInfo"`
+	// Config holds the kubelet settings. If missing, the kubelet defaults are used.
+	Config *kubeletconfig.KubeletConfiguration `json:"config,omitempty"`
+}
+
+// Workload is the set of pods to admit, in order: first Pods, then the pods found in Manifests.
+type Workload struct {
+	Pods []*v1.Pod `json:"pods,omitempty"`
+	// Manifests holds YAML or JSON documents, like the files cpumgrx reads.
+	Manifests string `json:"manifests,omitempty"`
+}
+
+type SimulateRequest struct {
+	NodeSpec
+	Workload
+}
+
+type CreateSessionRequest struct {
+	Name string `json:"name"`
+	NodeSpec
+}
+
+type PodResult struct {
+	Name string `json:"name"`
+	cpumgrx.AdmitResult
+	// Error is why the pod was rejected, empty if admitted.
+	Error    string `json:"error,omitempty"`
+	QOSClass string `json:"qosClass,omitempty"`
+	// Containers are what all the containers got, init containers first, empty if rejected.
+	Containers []ContainerResult `json:"containers,omitempty"`
+	// Log is what the managers logged while admitting the pod, if the server captures it.
+	Log []klogcapture.Entry `json:"log,omitempty"`
+}
+
+// ContainerResult is cpumgrx.ContainerResult with the CPU sets and the NUMA affinity as strings.
+type ContainerResult struct {
+	Name      string `json:"name"`
+	Init      bool   `json:"init,omitempty"`
+	CPUs      string `json:"cpus"`
+	Exclusive bool   `json:"exclusive"`
+	// SharedReason tells why the container got no exclusive CPUs.
+	SharedReason string `json:"sharedReason,omitempty"`
+	// NUMAAffinity is the NUMA mask of the topology manager hint, empty if none.
+	NUMAAffinity string `json:"numaAffinity,omitempty"`
+	Preferred    bool   `json:"preferred"`
+	NUMANodes    string `json:"numaNodes"`
+	Sockets      string `json:"sockets"`
+	UncoreCaches string `json:"uncoreCaches"`
+}
+
+type Result struct {
+	Pods []PodResult `json:"pods"`
+	// FreeCPUs are the CPUs which can still be allocated exclusively.
+	FreeCPUs string `json:"freeCPUs"`
+}
+
+type SessionStatus struct {
+	Name string `json:"name"`
+	Result
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+type session struct {
+	name string
+	// serializes the requests on this session
+	lock sync.Mutex
+	mgrx *cpumgrx.CpuMgrx
+	topo *topology.CPUTopology
+	// admitted pods, in admission order
+	pods []*v1.Pod
+}
+
+type Server struct {
+	lock     sync.Mutex
+	sessions map[string]*session
+}
+
+func New() *Server {
+	return &Server{
+		sessions: make(map[string]*session),
+	}
+}
+
+func (srv *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /v1/simulate", srv.simulate)
+	mux.HandleFunc("GET /v1/sessions", srv.listSessions)
+	mux.HandleFunc("POST /v1/sessions", srv.createSession)
+	mux.HandleFunc("GET /v1/sessions/{name}", srv.getSession)
+	mux.HandleFunc("DELETE /v1/sessions/{name}", srv.deleteSession)
+	mux.HandleFunc("POST /v1/sessions/{name}/pods", srv.addPods)
+	mux.HandleFunc("DELETE /v1/sessions/{name}/pods/{pod}", srv.removePod)
+	mux.HandleFunc("POST /v1/extender/filter", srv.extenderFilter)
+	mux.HandleFunc("POST /v1/extender/prioritize", srv.extenderPrioritize)
+	return mux
+}
+
+// Close releases all the sessions.
+func (srv *Server) Close() {
+	srv.lock.Lock()
+	defer srv.lock.Unlock()
+	for name, sess := range srv.sessions {
+		sess.close()
+		delete(srv.sessions, name)
+	}
+}
+
+func (srv *Server) simulate(w http.ResponseWriter, r *http.Request) {
+	var req SimulateRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+	pods, err := req.Workload.pods()
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	sess, err := srv.newSession("", req.NodeSpec)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	defer sess.close()
+
+	sess.lock.Lock()
+	defer sess.lock.Unlock()
+	res := Result{
+		Pods: sess.admit(pods),
+	}
+	res.FreeCPUs = sess.mgrx.GetFreeCPUs().String()
+	writeJSON(w, http.StatusOK, res)
+}
+
+func (srv *Server) listSessions(w http.ResponseWriter, r *http.Request) {
+	srv.lock.Lock()
+	names := []string{}
+	for name := range srv.sessions {
+		names = append(names, name)
+	}
+	srv.lock.Unlock()
+	sort.Strings(names)
+	writeJSON(w, http.StatusOK, names)
+}
+
+func (srv *Server) createSession(w http.ResponseWriter, r *http.Request) {
+	var req CreateSessionRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+	if req.Name == "" || strings.Contains(req.Name, "/") {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid session name %q", req.Name))
+		return
+	}
+
+	srv.lock.Lock()
+	_, exists := srv.sessions[req.Name]
+	srv.lock.Unlock()
+	if exists {
+		writeError(w, http.StatusConflict, fmt.Errorf("session %q already exists", req.Name))
+		return
+	}
+
+	sess, err := srv.newSession(req.Name, req.NodeSpec)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	srv.lock.Lock()
+	defer srv.lock.Unlock()
+	if _, exists := srv.sessions[req.Name]; exists {
+		// someone else was faster
+		sess.close()
+		writeError(w, http.StatusConflict, fmt.Errorf("session %q already exists", req.Name))
+		return
+	}
+	srv.sessions[req.Name] = sess
+	writeJSON(w, http.StatusCreated, sess.status())
+}
+
+func (srv *Server) getSession(w http.ResponseWriter, r *http.Request) {
+	sess, ok := srv.lookupSession(w, r)
+	if !ok {
+		return
+	}
+	sess.lock.Lock()
+	defer sess.lock.Unlock()
+	writeJSON(w, http.StatusOK, sess.status())
+}
+
+func (srv *Server) deleteSession(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	srv.lock.Lock()
+	sess, ok := srv.sessions[name]
+	delete(srv.sessions, name)
+	srv.lock.Unlock()
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Errorf("session %q not found", name))
+		return
+	}
+	sess.lock.Lock()
+	defer sess.lock.Unlock()
+	sess.close()
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (srv *Server) addPods(w http.ResponseWriter, r *http.Request) {
+	sess, ok := srv.lookupSession(w, r)
+	if !ok {
+		return
+	}
+	var req Workload
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+	pods, err := req.pods()
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	sess.lock.Lock()
+	defer sess.lock.Unlock()
+	for _, pod := range pods {
+		if sess.findPod(pod.Name) >= 0 {
+			writeError(w, http.StatusConflict, fmt.Errorf("pod %q already in session %q", pod.Name, sess.name))
+			return
+		}
+	}
+	res := Result{
+		Pods: sess.admit(pods),
+	}
+	res.FreeCPUs = sess.mgrx.GetFreeCPUs().String()
+	writeJSON(w, http.StatusOK, res)
+}
+
+func (srv *Server) removePod(w http.ResponseWriter, r *http.Request) {
+	sess, ok := srv.lookupSession(w, r)
+	if !ok {
+		return
+	}
+	podName := r.PathValue("pod")
+
+	sess.lock.Lock()
+	defer sess.lock.Unlock()
+	idx := sess.findPod(podName)
+	if idx < 0 {
+		writeError(w, http.StatusNotFound, fmt.Errorf("pod %q not found in session %q", podName, sess.name))
+		return
+	}
+	if err := sess.mgrx.Remove(sess.pods[idx]); err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	sess.pods = append(sess.pods[:idx], sess.pods[idx+1:]...)
+	writeJSON(w, http.StatusOK, sess.status())
+}
+
+func (srv *Server) lookupSession(w http.ResponseWriter, r *http.Request) (*session, bool) {
+	name := r.PathValue("name")
+	srv.lock.Lock()
+	sess, ok := srv.sessions[name]
+	srv.lock.Unlock()
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Errorf("session %q not found", name))
+	}
+	return sess, ok
+}
+
+func (srv *Server) newSession(name string, spec NodeSpec) (*session, error) {
+	if spec.MachineInfo == nil {
+		return nil, errors.New("missing machine info")
+	}
+	params := cpumgrx.Params{
+		MachineInfo: spec.MachineInfo,
+	}
+	kc := spec.Config
+	if kc == nil {
+		kc = &kubeletconfig.KubeletConfiguration{}
+	}
+	if err := kc.Apply(&params); err != nil {
+		return nil, err
+	}
+	if err := checkFeatureGates(params.FeatureGates); err != nil {
+		return nil, err
+	}
+	// nothing to set, and setting them would race with the other sessions
+	params.FeatureGates = nil
+	topo, err := topology.Discover(spec.MachineInfo)
+	if err != nil {
+		return nil, err
+	}
+
+	mgrx, err := cpumgrx.NewFromParams(params)
+	if err != nil {
+		return nil, err
+	}
+	return &session{
+		name: name,
+		mgrx: mgrx,
+		topo: topo,
+	}, nil
+}
+
+// checkFeatureGates rejects the gates which differ from the server ones. Feature gates are
+// process-wide: a session changing them would change them under all the other sessions.
+func checkFeatureGates(gates map[string]bool) error {
+	known := utilfeature.DefaultMutableFeatureGate.GetAll()
+	names := make([]string, 0, len(gates))
+	for name := range gates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		feature := featuregate.Feature(name)
+		if _, ok := known[feature]; !ok {
+			return fmt.Errorf("unrecognized feature gate: %s", name)
+		}
+		if enabled := utilfeature.DefaultFeatureGate.Enabled(feature); gates[name] != enabled {
+			return fmt.Errorf("feature gate %s=%v differs from the server setting %v: feature gates are process-wide, set them when starting the server", name, gates[name], enabled)
+		}
+	}
+	return nil
+}
+
+func (sess *session) close() {
+	if err := sess.mgrx.Close(); err != nil {
+		klog.Warningf("session %q: error releasing the manager: %v", sess.name, err)
+	}
+}
+
+func (sess *session) findPod(name string) int {
+	for idx, pod := range sess.pods {
+		if pod.Name == name {
+			return idx
+		}
+	}
+	return -1
+}
+
+// admit must be called with the session lock held
+func (sess *session) admit(pods []*v1.Pod) []PodResult {
+	res := []PodResult{}
+	for _, pod := range pods {
+		runRes, err := sess.mgrx.Run(pod)
+		pr := PodResult{
+			Name:        pod.Name,
+			AdmitResult: runRes.AdmitResult,
+			QOSClass:    string(runRes.QOSClass),
+			Containers:  containerResults(runRes.Containers),
+			Log:         runRes.Log,
+		}
+		if err != nil {
+			pr.Error = err.Error()
+		} else {
+			sess.pods = append(sess.pods, pod)
+		}
+		res = append(res, pr)
+	}
+	return res
+}
+
+// status must be called with the session lock held
+func (sess *session) status() SessionStatus {
+	st := SessionStatus{
+		Name: sess.name,
+		Result: Result{
+			Pods:     []PodResult{},
+			FreeCPUs: sess.mgrx.GetFreeCPUs().String(),
+		},
+	}
+	for _, pod := range sess.pods {
+		st.Pods = append(st.Pods, PodResult{
+			Name:        pod.Name,
+			AdmitResult: cpumgrx.AdmitResult{Admit: true},
+			QOSClass:    string(v1qos.GetPodQOS(pod)),
+			Containers:  containerResults(sess.mgrx.DescribeContainers(pod)),
+		})
+	}
+	return st
+}
+
+func containerResults(crs []cpumgrx.ContainerResult) []ContainerResult {
+	var res []ContainerResult
+	for _, cr := range crs {
+		out := ContainerResult{
+			Name:         cr.Name,
+			Init:         cr.Init,
+			CPUs:         cr.CPUs.String(),
+			Exclusive:    cr.Exclusive,
+			SharedReason: cr.SharedReason,
+			Preferred:    cr.Affinity.Preferred,
+			NUMANodes:    cr.NUMANodes.String(),
+			Sockets:      cr.Sockets.String(),
+			UncoreCaches: cr.UncoreCaches.String(),
+		}
+		if cr.Affinity.NUMANodeAffinity != nil {
+			out.NUMAAffinity = cr.Affinity.NUMANodeAffinity.String()
+		}
+		res = append(res, out)
+	}
+	return res
+}
+
+func (wl Workload) pods() ([]*v1.Pod, error) {
+	pods := append([]*v1.Pod{}, wl.Pods...)
+	if wl.Manifests != "" {
+		manifestPods, err := workload.Read(strings.NewReader(wl.Manifests))
+		if err != nil {
+			return nil, err
+		}
+		pods = append(pods, manifestPods...)
+	}
+	names := sets.New[string]()
+	for idx, pod := range pods {
+		if pod == nil || len(pod.Spec.Containers) == 0 {
+			return nil, fmt.Errorf("pod %d: no containers", idx)
+		}
+		if names.Has(pod.Name) {
+			return nil, fmt.Errorf("pod %q given more than once", pod.Name)
+		}
+		names.Insert(pod.Name)
+		if pod.UID == "" {
+			pod.UID = uuid.NewUUID()
+		}
+		workload.SetDefaults(pod)
+	}
+	return pods, nil
+}
+
+func decodeRequest(w http.ResponseWriter, r *http.Request, obj any) bool {
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodySize))
+	if err := dec.Decode(obj); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("malformed request: %w", err))
+		return false
+	}
+	return true
+}
+
+func writeError(w http.ResponseWriter, code int, err error) {
+	writeJSON(w, code, errorResponse{Error: err.Error()})
+}
+
+func writeJSON(w http.ResponseWriter, code int, obj any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(obj); err != nil {
+		klog.Warningf("error writing response: %v", err)
+	}
+}
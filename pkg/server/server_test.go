@@ -0,0 +1,259 @@
+/*
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2026 Red Hat, Inc.
+ */
+
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	utilfeature "k8s.io/apiserver/pkg/util/feature"
+	"k8s.io/component-base/featuregate"
+
+	"github.com/ffromani/cpumgrx/internal/testutil"
+	"github.com/ffromani/cpumgrx/pkg/kubeletconfig"
+)
+
+const testManifests = `
+apiVersion: v1
+kind: Pod
+metadata:
+  name: gu
+spec:
+  containers:
+  - name: cnt
+    resources:
+      limits:
+        cpu: "4"
+        memory: 1Gi
+      requests:
+        cpu: "4"
+        memory: 1Gi
+  - name: side
+    resources:
+      limits:
+        cpu: 500m
+        memory: 1Gi
+      requests:
+        cpu: 500m
+        memory: 1Gi
+---
+apiVersion: v1
+kind: Pod
+metadata:
+  name: huge
+spec:
+  containers:
+  - name: cnt
+    resources:
+      limits:
+        cpu: "64"
+        memory: 1Gi
+      requests:
+        cpu: "64"
+        memory: 1Gi
+`
+
+func TestSimulate(t *testing.T) {
+	ts := newTestServer(t)
+
+	var res Result
+	code := doRequest(t, ts, http.MethodPost, "/v1/simulate", SimulateRequest{
+		NodeSpec: testNodeSpec(t),
+		Workload: Workload{Manifests: testManifests},
+	}, &res)
+	if code != http.StatusOK {
+		t.Fatalf("unexpected status %d", code)
+	}
+	if len(res.Pods) != 2 {
+		t.Fatalf("unexpected pods: %+v", res.Pods)
+	}
+	if gu := res.Pods[0]; !gu.Admit || gu.Error != "" || gu.QOSClass != "Guaranteed" || len(gu.Containers) != 2 {
+		t.Errorf("unexpected result for %q: %+v", gu.Name, gu)
+	} else {
+		if cnt := gu.Containers[0]; cnt.Name != "cnt" || !cnt.Exclusive || cnt.CPUs == "" || cnt.NUMANodes == "" || cnt.SharedReason != "" {
+			t.Errorf("unexpected result for %q container %q: %+v", gu.Name, cnt.Name, cnt)
+		}
+		if side := gu.Containers[1]; side.Name != "side" || side.Exclusive || side.CPUs == "" || side.SharedReason == "" {
+			t.Errorf("unexpected result for %q container %q: %+v", gu.Name, side.Name, side)
+		}
+	}
+	if huge := res.Pods[1]; huge.Admit || huge.Cause == "" || huge.Error == "" || len(huge.Containers) != 0 {
+		t.Errorf("unexpected result for %q: %+v", huge.Name, huge)
+	}
+	if _, err := os.Stat("cpu_manager_state"); err == nil {
+		t.Errorf("state file written in the working directory")
+	}
+}
+
+func TestSessions(t *testing.T) {
+	ts := newTestServer(t)
+
+	var st SessionStatus
+	code := doRequest(t, ts, http.MethodPost, "/v1/sessions", CreateSessionRequest{
+		Name:     "test",
+		NodeSpec: testNodeSpec(t),
+	}, &st)
+	if code != http.StatusCreated {
+		t.Fatalf("unexpected status %d", code)
+	}
+	freeCPUs := st.FreeCPUs
+
+	code = doRequest(t, ts, http.MethodPost, "/v1/sessions", CreateSessionRequest{
+		Name:     "test",
+		NodeSpec: testNodeSpec(t),
+	}, nil)
+	if code != http.StatusConflict {
+		t.Errorf("duplicate session: unexpected status %d", code)
+	}
+
+	var res Result
+	code = doRequest(t, ts, http.MethodPost, "/v1/sessions/test/pods", Workload{Manifests: testManifests}, &res)
+	if code != http.StatusOK {
+		t.Fatalf("unexpected status %d", code)
+	}
+	if res.FreeCPUs == freeCPUs {
+		t.Errorf("free CPUs unchanged after admission: %q", res.FreeCPUs)
+	}
+
+	code = doRequest(t, ts, http.MethodGet, "/v1/sessions/test", nil, &st)
+	if code != http.StatusOK {
+		t.Fatalf("unexpected status %d", code)
+	}
+	if len(st.Pods) != 1 || st.Pods[0].Name != "gu" || len(st.Pods[0].Containers) != 2 || !st.Pods[0].Containers[0].Exclusive {
+		t.Errorf("unexpected session pods: %+v", st.Pods)
+	}
+
+	code = doRequest(t, ts, http.MethodPost, "/v1/sessions/test/pods", Workload{Manifests: testManifests}, nil)
+	if code != http.StatusConflict {
+		t.Errorf("pod already in session: unexpected status %d", code)
+	}
+	dupManifests := strings.ReplaceAll(testManifests, "name: huge", "name: other") + "---\n" + strings.ReplaceAll(testManifests, "name: huge", "name: other")
+	code = doRequest(t, ts, http.MethodPost, "/v1/sessions/test/pods", Workload{Manifests: dupManifests}, nil)
+	if code != http.StatusBadRequest {
+		t.Errorf("pod given twice: unexpected status %d", code)
+	}
+
+	code = doRequest(t, ts, http.MethodDelete, "/v1/sessions/test/pods/gu", nil, &st)
+	if code != http.StatusOK {
+		t.Fatalf("unexpected status %d", code)
+	}
+	if len(st.Pods) != 0 || st.FreeCPUs != freeCPUs {
+		t.Errorf("pod not released: %+v", st)
+	}
+
+	code = doRequest(t, ts, http.MethodDelete, "/v1/sessions/test", nil, nil)
+	if code != http.StatusNoContent {
+		t.Fatalf("unexpected status %d", code)
+	}
+	code = doRequest(t, ts, http.MethodGet, "/v1/sessions/test", nil, nil)
+	if code != http.StatusNotFound {
+		t.Errorf("deleted session: unexpected status %d", code)
+	}
+}
+
+func TestSessionFeatureGates(t *testing.T) {
+	ts := newTestServer(t)
+
+	const gate = "CPUManagerPolicyAlphaOptions"
+	enabled := utilfeature.DefaultFeatureGate.Enabled(featuregate.Feature(gate))
+
+	same := testNodeSpec(t)
+	same.Config.FeatureGates = map[string]bool{gate: enabled}
+	code := doRequest(t, ts, http.MethodPost, "/v1/sessions", CreateSessionRequest{
+		Name:     "same",
+		NodeSpec: same,
+	}, nil)
+	if code != http.StatusCreated {
+		t.Fatalf("unexpected status %d", code)
+	}
+
+	differ := testNodeSpec(t)
+	differ.Config.FeatureGates = map[string]bool{gate: !enabled}
+	code = doRequest(t, ts, http.MethodPost, "/v1/sessions", CreateSessionRequest{
+		Name:     "differ",
+		NodeSpec: differ,
+	}, nil)
+	if code != http.StatusBadRequest {
+		t.Errorf("differing feature gates: unexpected status %d", code)
+	}
+	if got := utilfeature.DefaultFeatureGate.Enabled(featuregate.Feature(gate)); got != enabled {
+		t.Errorf("feature gate %s changed by a session: %v", gate, got)
+	}
+
+	unknown := testNodeSpec(t)
+	unknown.Config.FeatureGates = map[string]bool{"NoSuchGate": true}
+	code = doRequest(t, ts, http.MethodPost, "/v1/sessions", CreateSessionRequest{
+		Name:     "unknown",
+		NodeSpec: unknown,
+	}, nil)
+	if code != http.StatusBadRequest {
+		t.Errorf("unknown feature gate: unexpected status %d", code)
+	}
+}
+
+func newTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	srv := New()
+	ts := httptest.NewServer(srv.Handler())
+	t.Cleanup(func() {
+		ts.Close()
+		srv.Close()
+	})
+	return ts
+}
+
+func testNodeSpec(t *testing.T) NodeSpec {
+	t.Helper()
+	return NodeSpec{
+		MachineInfo: testutil.ReadMachineInfo(t, "../../examples/machineinfo-v49-ryzen5950x.json"),
+		Config: &kubeletconfig.KubeletConfiguration{
+			CPUManagerPolicy:      "static",
+			ReservedSystemCPUs:    "0",
+			TopologyManagerPolicy: "single-numa-node",
+		},
+	}
+}
+
+func doRequest(t *testing.T, ts *httptest.Server, method, path string, body, out any) int {
+	t.Helper()
+	var payload bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&payload).Encode(body); err != nil {
+			t.Fatalf("error encoding request: %v", err)
+		}
+	}
+	req, err := http.NewRequest(method, ts.URL+path, &payload)
+	if err != nil {
+		t.Fatalf("error creating request: %v", err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("error sending request: %v", err)
+	}
+	defer resp.Body.Close()
+	if out != nil && resp.StatusCode < http.StatusMultipleChoices {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			t.Fatalf("error decoding response: %v", err)
+		}
+	}
+	return resp.StatusCode
+}
@@ -0,0 +1,101 @@
+/*
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2026 Red Hat, Inc.
+ */
+
+// Package sharedpool relates the shared CPU pool to the demand of the containers running on it.
+package sharedpool
+
+import (
+	"fmt"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/utils/cpuset"
+
+	"github.com/ffromani/cpumgrx/pkg/cpumgrx"
+)
+
+// Report is the demand on the shared pool.
+type Report struct {
+	CPUs cpuset.CPUSet `json:"cpus"`
+	// Containers is the amount of containers running on the pool
+	Containers int `json:"containers"`
+	// Requests is the sum of the CPU requests of the containers
+	Requests resource.Quantity `json:"requests"`
+	// Limits is the sum of the CPU limits of the containers which have one
+	Limits resource.Quantity `json:"limits"`
+	// Unbounded is the amount of containers without a CPU limit, which can use the whole pool
+	Unbounded int `json:"unbounded"`
+}
+
+// Overcommit is the ratio between what the containers can use and the pool size. The
+// containers without a limit count as using the whole pool. Above 1, the containers
+// can't all run at their limits at the same time.
+func (rp Report) Overcommit() float64 {
+	if rp.CPUs.IsEmpty() {
+		return 0
+	}
+	size := float64(rp.CPUs.Size())
+	return (float64(rp.Limits.MilliValue())/1000 + float64(rp.Unbounded)*size) / size
+}
+
+// Undersized tells if the containers request more CPUs than the pool has, which happens
+// when exclusive allocations shrink the pool after they were admitted.
+func (rp Report) Undersized() bool {
+	return rp.Requests.MilliValue() > int64(rp.CPUs.Size())*1000
+}
+
+func (rp Report) String() string {
+	return fmt.Sprintf("shared pool %q (%d CPUs): %d containers requests=%s limits=%s unbounded=%d overcommit=%.2f",
+		rp.CPUs.String(), rp.CPUs.Size(), rp.Containers, rp.Requests.String(), rp.Limits.String(), rp.Unbounded, rp.Overcommit())
+}
+
+// Compute reports about the containers of the pods which run on the shared pool: the ones which
+// got no exclusive CPUs. Init containers count only if they keep running, like sidecars.
+func Compute(mgrx *cpumgrx.CpuMgrx, pods []*v1.Pod) Report {
+	rp := Report{
+		CPUs:     mgrx.GetSharedCPUs(),
+		Requests: *resource.NewMilliQuantity(0, resource.DecimalSI),
+		Limits:   *resource.NewMilliQuantity(0, resource.DecimalSI),
+	}
+	for _, pod := range pods {
+		for _, cnt := range runningContainers(pod) {
+			if !mgrx.GetContainerExclusiveCPUs(pod, cnt.Name).IsEmpty() {
+				continue
+			}
+			rp.Containers++
+			if req, ok := cnt.Resources.Requests[v1.ResourceCPU]; ok {
+				rp.Requests.Add(req)
+			}
+			lim, ok := cnt.Resources.Limits[v1.ResourceCPU]
+			if !ok {
+				rp.Unbounded++
+				continue
+			}
+			rp.Limits.Add(lim)
+		}
+	}
+	return rp
+}
+
+func runningContainers(pod *v1.Pod) []v1.Container {
+	var cnts []v1.Container
+	for _, cnt := range pod.Spec.InitContainers {
+		if cnt.RestartPolicy != nil && *cnt.RestartPolicy == v1.ContainerRestartPolicyAlways {
+			cnts = append(cnts, cnt)
+		}
+	}
+	return append(cnts, pod.Spec.Containers...)
+}
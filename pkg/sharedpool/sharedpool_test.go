@@ -0,0 +1,136 @@
+/*
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2026 Red Hat, Inc.
+ */
+
+package sharedpool
+
+import (
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/cpuset"
+
+	"github.com/ffromani/cpumgrx/internal/testutil"
+	"github.com/ffromani/cpumgrx/pkg/cpumgrx"
+)
+
+func TestCompute(t *testing.T) {
+	params := cpumgrx.Params{
+		PolicyName:     "static",
+		TMPolicyName:   "single-numa-node",
+		MachineInfo:    testutil.ReadMachineInfo(t, "../../examples/machineinfo-v49-ryzen5950x.json"),
+		ReservedCPUQty: resource.MustParse("1"),
+		ReservedCPUSet: cpuset.New(0),
+	}
+	mgrx, err := cpumgrx.NewFromParams(params)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer mgrx.Close()
+
+	pods := []*v1.Pod{
+		makePod("burstable", "2", "8"),
+		makePod("besteffort", "", ""),
+		makePod("guaranteed", "30", "30"),
+		makePod("late", "1", "2"),
+	}
+	var admitted []*v1.Pod
+	for _, pod := range pods {
+		if _, err := mgrx.Run(pod); err != nil {
+			t.Fatalf("pod %q rejected: %v", pod.Name, err)
+		}
+		admitted = append(admitted, pod)
+	}
+
+	rp := Compute(mgrx, admitted)
+	if rp.CPUs.Size() != 2 || rp.Containers != 3 || rp.Unbounded != 1 {
+		t.Errorf("unexpected report: %s", rp.String())
+	}
+	if rp.Requests.Cmp(resource.MustParse("3")) != 0 || rp.Limits.Cmp(resource.MustParse("10")) != 0 {
+		t.Errorf("unexpected demand: %s", rp.String())
+	}
+	// the unbounded container counts as the whole pool: (10 + 2) / 2
+	if got := rp.Overcommit(); got != 6 {
+		t.Errorf("got overcommit %v expected 6", got)
+	}
+	if !rp.Undersized() {
+		t.Errorf("pool not undersized: %s", rp.String())
+	}
+}
+
+func makePod(name, request, limit string) *v1.Pod {
+	cnt := v1.Container{Name: "cnt"}
+	// the guaranteed QoS class needs memory limits too
+	if request != "" {
+		cnt.Resources.Requests = v1.ResourceList{
+			v1.ResourceCPU:    resource.MustParse(request),
+			v1.ResourceMemory: resource.MustParse("1Gi"),
+		}
+	}
+	if limit != "" {
+		cnt.Resources.Limits = v1.ResourceList{
+			v1.ResourceCPU:    resource.MustParse(limit),
+			v1.ResourceMemory: resource.MustParse("1Gi"),
+		}
+	}
+	pod := v1.Pod{
+		Spec: v1.PodSpec{
+			Containers: []v1.Container{cnt},
+		},
+	}
+	pod.Name = name
+	pod.UID = types.UID(name + "-uid")
+	return &pod
+}
+
+func TestComparePlacements(t *testing.T) {
+	params := cpumgrx.Params{
+		PolicyName:     "static",
+		TMPolicyName:   "single-numa-node",
+		MachineInfo:    testutil.ReadMachineInfo(t, "../../examples/machineinfo-v49-ryzen5950x.json"),
+		ReservedCPUQty: resource.MustParse("2"),
+		ReservedCPUSet: cpuset.New(0, 16),
+	}
+	mgrx, err := cpumgrx.NewFromParams(params)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer mgrx.Close()
+
+	pods := []*v1.Pod{
+		makePod("besteffort", "", ""),
+		makePod("guaranteed", "30", "30"),
+	}
+	for _, pod := range pods {
+		if _, err := mgrx.Run(pod); err != nil {
+			t.Fatalf("pod %q rejected: %v", pod.Name, err)
+		}
+	}
+
+	for _, pl := range ComparePlacements(mgrx, pods) {
+		if !pl.Loose.Equals(cpuset.New(0, 16)) || !pl.StrictEmpty() {
+			t.Errorf("%s: unexpected placement %+v", pl.QOSClass, pl)
+		}
+		expected := 0
+		if pl.QOSClass == v1.PodQOSBestEffort {
+			expected = 1
+		}
+		if pl.Containers != expected {
+			t.Errorf("%s: got %d containers expected %d", pl.QOSClass, pl.Containers, expected)
+		}
+	}
+}
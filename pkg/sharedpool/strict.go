@@ -0,0 +1,73 @@
+/*
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2026 Red Hat, Inc.
+ */
+
+package sharedpool
+
+import (
+	v1 "k8s.io/api/core/v1"
+	v1qos "k8s.io/kubernetes/pkg/apis/core/v1/helper/qos"
+	"k8s.io/utils/cpuset"
+
+	"github.com/ffromani/cpumgrx/pkg/cpumgrx"
+)
+
+// Placement is where the containers of a QoS class without exclusive CPUs run.
+// The cpu manager puts them all on the same shared pool, which with the
+// strict-cpu-reservation option doesn't include the reserved CPUs anymore.
+type Placement struct {
+	QOSClass v1.PodQOSClass `json:"qosClass"`
+	// Containers is the amount of containers of the class running on the shared pool
+	Containers int `json:"containers"`
+	// Loose is the shared pool without strict-cpu-reservation
+	Loose cpuset.CPUSet `json:"loose"`
+	// Strict is the shared pool with strict-cpu-reservation
+	Strict cpuset.CPUSet `json:"strict"`
+}
+
+// StrictEmpty tells if the containers of the class would have no CPUs to run on with strict-cpu-reservation.
+func (pl Placement) StrictEmpty() bool {
+	return pl.Strict.IsEmpty()
+}
+
+// ComparePlacements returns the placement of the containers running on the shared pool,
+// per QoS class, with and without strict-cpu-reservation, whatever the manager uses.
+// The exclusive allocations are the same either way, since they never take reserved CPUs.
+func ComparePlacements(mgrx *cpumgrx.CpuMgrx, pods []*v1.Pod) []Placement {
+	shared := mgrx.GetSharedCPUs()
+	reserved := mgrx.GetReservedCPUs()
+	loose := shared.Union(reserved)
+	strict := shared.Difference(reserved)
+
+	placements := []Placement{
+		{QOSClass: v1.PodQOSGuaranteed, Loose: loose, Strict: strict},
+		{QOSClass: v1.PodQOSBurstable, Loose: loose, Strict: strict},
+		{QOSClass: v1.PodQOSBestEffort, Loose: loose, Strict: strict},
+	}
+	for _, pod := range pods {
+		qosClass := v1qos.GetPodQOS(pod)
+		for idx := range placements {
+			if placements[idx].QOSClass != qosClass {
+				continue
+			}
+			for _, cnt := range runningContainers(pod) {
+				if mgrx.GetContainerExclusiveCPUs(pod, cnt.Name).IsEmpty() {
+					placements[idx].Containers++
+				}
+			}
+		}
+	}
+	return placements
+}
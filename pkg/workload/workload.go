@@ -0,0 +1,207 @@
+/*
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2026 Red Hat, Inc.
+ */
+
+// Package workload turns manifests into the pods the kubelet would eventually
+// admit. Manifests may hold many documents; workload objects are expanded into
+// one pod per replica, objects without a pod template are skipped.
+package workload
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/uuid"
+	k8syaml "k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/klog/v2"
+	corev1 "k8s.io/kubernetes/pkg/apis/core/v1"
+)
+
+func ReadFile(path string) ([]*v1.Pod, error) {
+	src, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer src.Close()
+	return Read(src)
+}
+
+// Read decodes all the YAML or JSON documents from the reader, and returns the pods they describe.
+func Read(r io.Reader) ([]*v1.Pod, error) {
+	var pods []*v1.Pod
+	dec := k8syaml.NewYAMLOrJSONDecoder(r, 4096)
+	for idx := 0; ; idx++ {
+		var raw runtime.RawExtension
+		err := dec.Decode(&raw)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("document %d: %w", idx, err)
+		}
+		if len(raw.Raw) == 0 || string(raw.Raw) == "null" {
+			continue // empty document
+		}
+		docPods, err := podsFromDocument(raw.Raw)
+		if err != nil {
+			return nil, fmt.Errorf("document %d: %w", idx, err)
+		}
+		pods = append(pods, docPods...)
+	}
+	return pods, nil
+}
+
+func podsFromDocument(data []byte) ([]*v1.Pod, error) {
+	var tm metav1.TypeMeta
+	if err := json.Unmarshal(data, &tm); err != nil {
+		return nil, err
+	}
+
+	switch tm.Kind {
+	case "List", "PodList":
+		// like the output of kubectl get -o json
+		var list struct {
+			Items []json.RawMessage `json:"items"`
+		}
+		if err := json.Unmarshal(data, &list); err != nil {
+			return nil, err
+		}
+		var pods []*v1.Pod
+		for idx, item := range list.Items {
+			itemPods, err := podsFromDocument(item)
+			if err != nil {
+				return nil, fmt.Errorf("item %d: %w", idx, err)
+			}
+			pods = append(pods, itemPods...)
+		}
+		return pods, nil
+	case "Pod", "":
+		// plain pod specs used to come without any kind
+		var pod v1.Pod
+		if err := json.Unmarshal(data, &pod); err != nil {
+			return nil, err
+		}
+		if pod.UID == "" {
+			pod.UID = uuid.NewUUID()
+		}
+		SetDefaults(&pod)
+		return []*v1.Pod{&pod}, nil
+	case "Deployment":
+		var obj appsv1.Deployment
+		if err := json.Unmarshal(data, &obj); err != nil {
+			return nil, err
+		}
+		return expand(obj.ObjectMeta, &obj.Spec.Template, replicasOrDefault(obj.Spec.Replicas)), nil
+	case "StatefulSet":
+		var obj appsv1.StatefulSet
+		if err := json.Unmarshal(data, &obj); err != nil {
+			return nil, err
+		}
+		return expand(obj.ObjectMeta, &obj.Spec.Template, replicasOrDefault(obj.Spec.Replicas)), nil
+	case "DaemonSet":
+		var obj appsv1.DaemonSet
+		if err := json.Unmarshal(data, &obj); err != nil {
+			return nil, err
+		}
+		// we simulate a single node, which runs exactly one instance
+		return expand(obj.ObjectMeta, &obj.Spec.Template, 1), nil
+	case "Job":
+		var obj batchv1.Job
+		if err := json.Unmarshal(data, &obj); err != nil {
+			return nil, err
+		}
+		return expand(obj.ObjectMeta, &obj.Spec.Template, jobPods(obj.Spec)), nil
+	}
+	klog.V(2).Infof("skipping %s %q: no pod template", tm.Kind, objectName(data))
+	return nil, nil
+}
+
+// OnNode returns the pods bound to the given node which still hold resources,
+// in creation order, which is our best guess of the order the kubelet admitted them.
+func OnNode(pods []*v1.Pod, nodeName string) []*v1.Pod {
+	var res []*v1.Pod
+	for _, pod := range pods {
+		if pod.Spec.NodeName != nodeName {
+			continue
+		}
+		if pod.Status.Phase == v1.PodSucceeded || pod.Status.Phase == v1.PodFailed {
+			klog.V(2).Infof("skipping pod %s/%s: phase %s", pod.Namespace, pod.Name, pod.Status.Phase)
+			continue
+		}
+		res = append(res, pod)
+	}
+	sort.SliceStable(res, func(i, j int) bool {
+		return res[i].CreationTimestamp.Before(&res[j].CreationTimestamp)
+	})
+	return res
+}
+
+// SetDefaults fills the pod like the API server does when storing it: most notably,
+// the container requests default to their limits, which decides the QoS class.
+func SetDefaults(pod *v1.Pod) {
+	corev1.SetDefaults_Pod(pod)
+}
+
+// expand creates the given amount of pods out of the template, with distinct names and UIDs.
+func expand(owner metav1.ObjectMeta, tmpl *v1.PodTemplateSpec, replicas int) []*v1.Pod {
+	var pods []*v1.Pod
+	for idx := 0; idx < replicas; idx++ {
+		pod := v1.Pod{
+			ObjectMeta: *tmpl.ObjectMeta.DeepCopy(),
+			Spec:       *tmpl.Spec.DeepCopy(),
+		}
+		pod.Name = fmt.Sprintf("%s-%d", owner.Name, idx)
+		pod.Namespace = owner.Namespace
+		pod.UID = uuid.NewUUID()
+		SetDefaults(&pod)
+		pods = append(pods, &pod)
+	}
+	return pods
+}
+
+func replicasOrDefault(replicas *int32) int {
+	if replicas == nil {
+		return 1
+	}
+	return int(*replicas)
+}
+
+// jobPods returns the amount of pods of the job running at the same time.
+func jobPods(spec batchv1.JobSpec) int {
+	parallelism := replicasOrDefault(spec.Parallelism)
+	if spec.Completions != nil && int(*spec.Completions) < parallelism {
+		return int(*spec.Completions)
+	}
+	return parallelism
+}
+
+func objectName(data []byte) string {
+	var obj struct {
+		Metadata metav1.ObjectMeta `json:"metadata"`
+	}
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return ""
+	}
+	return obj.Metadata.Name
+}
@@ -0,0 +1,189 @@
+/*
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2026 Red Hat, Inc.
+ */
+
+package workload
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	v1qos "k8s.io/kubernetes/pkg/apis/core/v1/helper/qos"
+)
+
+const bundle = `apiVersion: v1
+kind: Service
+metadata:
+  name: web
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  replicas: 2
+  template:
+    spec:
+      containers:
+      - name: web
+---
+---
+apiVersion: apps/v1
+kind: StatefulSet
+metadata:
+  name: db
+spec:
+  template:
+    spec:
+      containers:
+      - name: db
+---
+apiVersion: batch/v1
+kind: Job
+metadata:
+  name: batch
+spec:
+  parallelism: 4
+  completions: 3
+  template:
+    spec:
+      containers:
+      - name: batch
+---
+apiVersion: v1
+kind: Pod
+metadata:
+  name: plain
+spec:
+  containers:
+  - name: plain
+`
+
+func TestRead(t *testing.T) {
+	pods, err := Read(strings.NewReader(bundle))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var names []string
+	uids := make(map[types.UID]bool)
+	for _, pod := range pods {
+		names = append(names, pod.Name)
+		uids[pod.UID] = true
+	}
+	expected := []string{"web-0", "web-1", "db-0", "batch-0", "batch-1", "batch-2", "plain"}
+	if !reflect.DeepEqual(names, expected) {
+		t.Errorf("got pods %v expected %v", names, expected)
+	}
+	if len(uids) != len(pods) {
+		t.Errorf("UIDs are not unique: %v", uids)
+	}
+}
+
+func TestReadError(t *testing.T) {
+	_, err := Read(strings.NewReader("kind: Deployment\nspec: [\n"))
+	if err == nil {
+		t.Errorf("expected error, got none")
+	}
+}
+
+const limitsOnly = `apiVersion: v1
+kind: Pod
+metadata:
+  name: plain
+spec:
+  initContainers:
+  - name: init
+    resources:
+      limits:
+        cpu: "1"
+        memory: 100Mi
+  containers:
+  - name: cnt
+    resources:
+      limits:
+        cpu: "2"
+        memory: 1Gi
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  template:
+    spec:
+      containers:
+      - name: web
+        resources:
+          limits:
+            cpu: "4"
+            memory: 1Gi
+          requests:
+            memory: 1Gi
+`
+
+func TestReadLimitsOnly(t *testing.T) {
+	pods, err := Read(strings.NewReader(limitsOnly))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(pods) != 2 {
+		t.Fatalf("got %d pods expected 2", len(pods))
+	}
+	for _, pod := range pods {
+		if qosClass := v1qos.GetPodQOS(pod); qosClass != v1.PodQOSGuaranteed {
+			t.Errorf("pod %q: got QoS class %s expected %s", pod.Name, qosClass, v1.PodQOSGuaranteed)
+		}
+		cnts := append(append([]v1.Container{}, pod.Spec.InitContainers...), pod.Spec.Containers...)
+		for _, cnt := range cnts {
+			if !reflect.DeepEqual(cnt.Resources.Requests, cnt.Resources.Limits) {
+				t.Errorf("pod %q container %q: got requests %v expected %v", pod.Name, cnt.Name, cnt.Resources.Requests, cnt.Resources.Limits)
+			}
+		}
+	}
+}
+
+const podList = `{
+  "apiVersion": "v1",
+  "kind": "List",
+  "items": [
+    {"kind": "Pod", "metadata": {"name": "late", "uid": "uid-late", "creationTimestamp": "2026-01-02T00:00:00Z"}, "spec": {"nodeName": "node-a"}},
+    {"kind": "Pod", "metadata": {"name": "other", "uid": "uid-other", "creationTimestamp": "2026-01-01T00:00:00Z"}, "spec": {"nodeName": "node-b"}},
+    {"kind": "Pod", "metadata": {"name": "done", "uid": "uid-done", "creationTimestamp": "2026-01-01T00:00:00Z"}, "spec": {"nodeName": "node-a"}, "status": {"phase": "Succeeded"}},
+    {"kind": "Pod", "metadata": {"name": "early", "uid": "uid-early", "creationTimestamp": "2026-01-01T00:00:00Z"}, "spec": {"nodeName": "node-a"}, "status": {"phase": "Running"}}
+  ]
+}`
+
+func TestOnNode(t *testing.T) {
+	pods, err := Read(strings.NewReader(podList))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(pods) != 4 {
+		t.Fatalf("got %d pods expected 4", len(pods))
+	}
+
+	var names []string
+	for _, pod := range OnNode(pods, "node-a") {
+		names = append(names, pod.Name+"/"+string(pod.UID))
+	}
+	expected := []string{"early/uid-early", "late/uid-late"}
+	if !reflect.DeepEqual(names, expected) {
+		t.Errorf("got pods %v expected %v", names, expected)
+	}
+}